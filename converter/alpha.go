@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Coverage remapping and alpha storage. The atlas canvas is an image.RGBA,
+// i.e. premultiplied: every channel holds coverage times the (white) fill.
+
+// Alpha storage modes for the atlas image.
+const (
+	AlphaPremultiplied = "premultiplied"
+	AlphaStraight      = "straight"
+)
+
+// validateCoverage checks the gamma, contrast and alpha mode options
+// (0 and "" select the defaults).
+func validateCoverage(gamma, contrast float64, alpha string) error {
+	if gamma < 0 || gamma > 10 {
+		return fmt.Errorf("gamma must be between 0 and 10")
+	}
+	if contrast < 0 || contrast > 10 {
+		return fmt.Errorf("contrast must be between 0 and 10")
+	}
+	switch alpha {
+	case "", AlphaPremultiplied, AlphaStraight:
+		return nil
+	}
+	return fmt.Errorf("unknown alpha mode %q (use 'premultiplied' or 'straight')", alpha)
+}
+
+// coverageCurve maps coverage through the gamma curve c^(1/gamma) (above 1
+// thickens glyphs) and then the contrast S-curve c^k / (c^k + (1-c)^k)
+// (above 1 sharpens edges). Both keep 0 and 1 fixed. It returns nil when
+// the curve is the identity.
+func coverageCurve(gamma, contrast float64) *[256]uint8 {
+	if gamma == 0 {
+		gamma = 1
+	}
+	if contrast == 0 {
+		contrast = 1
+	}
+	if gamma == 1 && contrast == 1 {
+		return nil
+	}
+	var lut [256]uint8
+	for i := range lut {
+		c := math.Pow(float64(i)/255, 1/gamma)
+		if c > 0 && c < 1 {
+			hi, lo := math.Pow(c, contrast), math.Pow(1-c, contrast)
+			c = hi / (hi + lo)
+		}
+		lut[i] = uint8(math.Round(c * 255))
+	}
+	return &lut
+}
+
+// remapCoverage applies lut to every channel of img. Since the fill is
+// white (or per-channel coverage for LCD), each channel is a coverage value.
+func remapCoverage(img *image.RGBA, lut *[256]uint8) {
+	for i, v := range img.Pix {
+		img.Pix[i] = lut[v]
+	}
+}
+
+// atlasImage returns img in the form the encoder for format must receive so
+// that the file stores alpha as requested. The PNG encoder un-premultiplies
+// an image.RGBA, while EncodeBMP writes its values as they are; the default
+// ("") keeps that behaviour (straight PNG, premultiplied BMP). LCD atlases
+// hold per-subpixel coverage rather than colours, and are always written
+// as they are, whatever alpha says.
+func atlasImage(img *image.RGBA, format, alpha string, lcd bool) image.Image {
+	switch {
+	case lcd && format != "bmp":
+		return &image.NRGBA{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect}
+	case lcd:
+		return img
+	case alpha == AlphaPremultiplied && format != "bmp":
+		// Store the premultiplied values as they are.
+		return &image.NRGBA{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect}
+	case alpha == AlphaStraight && format == "bmp":
+		// Un-premultiply into an RGBA that EncodeBMP copies verbatim.
+		out := image.NewRGBA(img.Rect)
+		for i := 0; i < len(img.Pix); i += 4 {
+			a := uint32(img.Pix[i+3])
+			if a == 0 {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				out.Pix[i+c] = uint8((uint32(img.Pix[i+c])*255 + a/2) / a)
+			}
+			out.Pix[i+3] = uint8(a)
+		}
+		return out
+	}
+	return img
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestCoverageCurve(t *testing.T) {
+	if coverageCurve(0, 0) != nil || coverageCurve(1, 1) != nil {
+		t.Error("expected no curve for the defaults")
+	}
+	for _, tc := range []struct{ gamma, contrast float64 }{{2.2, 1}, {1, 2}, {0.5, 0.5}} {
+		lut := coverageCurve(tc.gamma, tc.contrast)
+		if lut[0] != 0 || lut[255] != 255 {
+			t.Errorf("gamma=%v contrast=%v: endpoints %d, %d", tc.gamma, tc.contrast, lut[0], lut[255])
+		}
+		for i := 1; i < 256; i++ {
+			if lut[i] < lut[i-1] {
+				t.Fatalf("gamma=%v contrast=%v: not monotonic at %d", tc.gamma, tc.contrast, i)
+			}
+		}
+	}
+	if lut := coverageCurve(2.2, 1); lut[64] <= 64 {
+		t.Errorf("gamma 2.2 should thicken: 64 -> %d", lut[64])
+	}
+	if lut := coverageCurve(1, 2); lut[64] >= 64 || lut[192] <= 192 {
+		t.Errorf("contrast 2 should sharpen: 64 -> %d, 192 -> %d", lut[64], lut[192])
+	}
+}
+
+func TestAlphaModes(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	// Returns the first partially covered pixel, as stored in the file.
+	render := func(format, alpha string) color.NRGBA {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "font")
+		opts := Options{Size: 16, Chars: "O", Format: format, Hinting: "none", Alpha: alpha}
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatal(err)
+		}
+		var img image.Image
+		if format == "png" {
+			img = readPNG(t, prefix+".png")
+		} else {
+			img = readBMP(t, prefix+".bmp")
+		}
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA); c.A > 0 && c.A < 255 {
+					return c
+				}
+			}
+		}
+		t.Fatalf("%s/%s: no partially covered pixel", format, alpha)
+		return color.NRGBA{}
+	}
+
+	for _, tc := range []struct {
+		format, alpha string
+		straight      bool
+	}{
+		{"png", "", true},
+		{"png", AlphaPremultiplied, false},
+		{"bmp", "", false},
+		{"bmp", AlphaStraight, true},
+	} {
+		c := render(tc.format, tc.alpha)
+		if tc.straight && c.R != 255 {
+			t.Errorf("%s/%q: %v, want straight white", tc.format, tc.alpha, c)
+		}
+		if !tc.straight && c.R != c.A {
+			t.Errorf("%s/%q: %v, want premultiplied white", tc.format, tc.alpha, c)
+		}
+	}
+}
+
+// readBMP decodes a top-down 32-bit BMP written by EncodeBMP, keeping the
+// stored bytes as they are.
+func readBMP(t *testing.T, path string) *image.NRGBA {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := binary.LittleEndian.Uint32(data[10:])
+	width := int(int32(binary.LittleEndian.Uint32(data[18:])))
+	height := -int(int32(binary.LittleEndian.Uint32(data[22:])))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		p := data[int(offset)+4*i:]
+		img.Pix[4*i], img.Pix[4*i+1], img.Pix[4*i+2], img.Pix[4*i+3] = p[2], p[1], p[0], p[3]
+	}
+	return img
+}
@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// Legacy code pages: with Options.Charset, char ids are a character's bytes
+// in that code page (big-endian for multi-byte code pages such as Shift-JIS)
+// instead of its Unicode code point, as BMFont does with unicode=0.
+
+// bmfontCharsets are the names BMFont writes for the Windows code pages.
+var bmfontCharsets = map[string]string{
+	"windows-1250": "EASTEUROPE",
+	"windows-1251": "RUSSIAN",
+	"windows-1252": "ANSI",
+	"windows-1253": "GREEK",
+	"windows-1254": "TURKISH",
+	"windows-1255": "HEBREW",
+	"windows-1256": "ARABIC",
+	"windows-1257": "BALTIC",
+	"windows-1258": "VIETNAMESE",
+	"windows-874":  "THAI",
+	"ibm437":       "OEM",
+	"shift_jis":    "SHIFTJIS",
+	"gbk":          "GB2312",
+	"gb2312":       "GB2312",
+	"euc-kr":       "HANGUL",
+	"big5":         "CHINESEBIG5",
+}
+
+// codePage converts runes to char ids in a legacy code page.
+type codePage struct {
+	name    string // The info line's charset attribute
+	encoder *encoding.Encoder
+}
+
+// newCodePage looks up an IANA code page name (e.g. "windows-1252",
+// "ISO-8859-2" or "Shift_JIS"). An empty name means Unicode ids (nil).
+func newCodePage(name string) (*codePage, error) {
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unknown charset %q", name)
+	}
+	canonical, err := ianaindex.MIME.Name(enc)
+	if err != nil {
+		canonical, _ = ianaindex.IANA.Name(enc)
+	}
+	if strings.HasPrefix(strings.ToUpper(canonical), "UTF-") {
+		return nil, fmt.Errorf("charset %q is Unicode; leave the charset empty for Unicode ids", name)
+	}
+
+	cp := &codePage{name: canonical, encoder: enc.NewEncoder()}
+	if bmfont, ok := bmfontCharsets[strings.ToLower(canonical)]; ok {
+		cp.name = bmfont
+	}
+	return cp, nil
+}
+
+// ValidateCharset checks that a --charset name is a supported code page.
+func ValidateCharset(name string) error {
+	_, err := newCodePage(name)
+	return err
+}
+
+// id returns the char id of r: its bytes in the code page, big-endian. It
+// reports false if the code page has no such character.
+func (c *codePage) id(r rune) (int, bool) {
+	b, err := c.encoder.String(string(r))
+	if err != nil || len(b) == 0 || len(b) > 4 {
+		return 0, false
+	}
+	id := 0
+	for i := 0; i < len(b); i++ {
+		id = id<<8 | int(b[i])
+	}
+	return id, true
+}
@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestCodePageIDs(t *testing.T) {
+	tests := []struct {
+		charset string
+		r       rune
+		id      int
+		ok      bool
+	}{
+		{"windows-1252", 'A', 0x41, true},
+		{"windows-1252", '€', 0x80, true},
+		{"windows-1252", 'ő', 0, false},
+		{"ISO-8859-2", 'ő', 0xF5, true},
+		{"Shift_JIS", 'ア', 0x8341, true},
+		{"Shift_JIS", 'A', 0x41, true},
+	}
+	for _, tt := range tests {
+		cp, err := newCodePage(tt.charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, ok := cp.id(tt.r); id != tt.id || ok != tt.ok {
+			t.Errorf("%s %q: id 0x%X (%v), want 0x%X (%v)", tt.charset, tt.r, id, ok, tt.id, tt.ok)
+		}
+	}
+
+	for _, name := range []string{"bogus", "UTF-8"} {
+		if err := ValidateCharset(name); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCharsetOutput(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	render := func(charset string) (string, Stats) {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "font")
+		stats, err := GenerateWithStats(path, prefix, Options{Size: 16, Chars: "Aé€ő", Format: "png", Charset: charset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		return string(fnt), stats
+	}
+
+	fnt, stats := render("")
+	if !strings.Contains(fnt, ` charset="" unicode=1 `) || !strings.Contains(fnt, "char id=337 ") || stats.Unmapped != "" {
+		t.Errorf("unicode output:\n%s", fnt)
+	}
+
+	fnt, stats = render("windows-1252")
+	if !strings.Contains(fnt, ` charset="ANSI" unicode=0 `) || !strings.Contains(fnt, "chars count=3") {
+		t.Errorf("windows-1252 output:\n%s", fnt)
+	}
+	for _, want := range []string{"char id=65 ", "char id=233 ", "char id=128 "} {
+		if !strings.Contains(fnt, want) {
+			t.Errorf("windows-1252 output lacks %q:\n%s", want, fnt)
+		}
+	}
+	if stats.Unmapped != "ő" {
+		t.Errorf("unmapped %q, want \"ő\"", stats.Unmapped)
+	}
+
+	fnt, _ = render("ISO-8859-2")
+	if !strings.Contains(fnt, ` charset="ISO-8859-2" unicode=0 `) || !strings.Contains(fnt, "char id=245 ") {
+		t.Errorf("ISO-8859-2 output:\n%s", fnt)
+	}
+}
@@ -0,0 +1,39 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"image"
+)
+
+// Glyph deduplication: every rasterised glyph is hashed, and characters whose
+// bitmaps are identical point to the same atlas rectangle.
+
+// tileKey identifies a glyph bitmap by its size and a hash of its pixels.
+type tileKey [sha256.Size]byte
+
+func newTileKey(img *image.RGBA) tileKey {
+	h := sha256.New()
+	var dims [8]byte
+	binary.LittleEndian.PutUint32(dims[:4], uint32(img.Rect.Dx()))
+	binary.LittleEndian.PutUint32(dims[4:], uint32(img.Rect.Dy()))
+	h.Write(dims[:])
+	h.Write(img.Pix)
+	var key tileKey
+	h.Sum(key[:0])
+	return key
+}
+
+// stats counts the glyphs, distinct bitmaps and the area shared bitmaps save.
+func (lay *atlasLayout) stats() Stats {
+	s := Stats{Glyphs: len(lay.cells), Bitmaps: len(lay.tiles), Overflow: string(lay.overflow), Missing: string(lay.missing)}
+	for _, c := range lay.cells {
+		w, h := lay.rectSize(lay.tiles[c.tile])
+		s.SavedPixels += w * h
+	}
+	for _, t := range lay.tiles {
+		w, h := lay.rectSize(t)
+		s.SavedPixels -= w * h
+	}
+	return s
+}
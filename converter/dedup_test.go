@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestDedupGlyphs(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	prefix := filepath.Join(t.TempDir(), "font")
+
+	// Latin, Greek and Cyrillic capitals A and B, plus space and no-break space.
+	stats, err := GenerateWithStats(path, prefix, Options{Size: 24, Chars: "AΑАBΒВ \u00a0", Format: "png", Spacing: [2]int{1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Glyphs != 8 || stats.Bitmaps != 3 {
+		t.Errorf("stats %+v, want 8 glyphs in 3 bitmaps", stats)
+	}
+	if stats.SavedPixels <= 0 {
+		t.Errorf("stats %+v, want saved pixels", stats)
+	}
+
+	fnt, _ := os.ReadFile(prefix + ".fnt")
+	rects := make(map[rune]string)
+	for _, line := range strings.Split(string(fnt), "\n") {
+		var id, x, y, w int
+		if _, err := fmt.Sscanf(line, "char id=%d x=%d y=%d width=%d", &id, &x, &y, &w); err == nil {
+			rects[rune(id)] = fmt.Sprintf("%d,%d %d", x, y, w)
+		}
+	}
+	for _, same := range []string{"AΑА", "BΒВ"} {
+		r := []rune(same)
+		if rects[r[0]] != rects[r[1]] || rects[r[0]] != rects[r[2]] {
+			t.Errorf("%s not shared: %q %q %q", same, rects[r[0]], rects[r[1]], rects[r[2]])
+		}
+	}
+	if rects[' '] != rects['\u00a0'] {
+		t.Errorf("spaces not shared: %q %q", rects[' '], rects['\u00a0'])
+	}
+	if rects['A'] == rects['B'] {
+		t.Errorf("A and B share %q", rects['A'])
+	}
+	if len(rects) != 8 {
+		t.Errorf("%d chars in the FNT, want 8", len(rects))
+	}
+
+	// Nothing is shared when every glyph differs.
+	stats, err = GenerateWithStats(path, prefix, Options{Size: 24, Chars: "ABC", Format: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Glyphs != 3 || stats.Bitmaps != 3 || stats.SavedPixels != 0 || stats.Overflow != "" || stats.Unmapped != "" {
+		t.Errorf("stats %+v, want 3 distinct bitmaps", stats)
+	}
+}
@@ -0,0 +1,59 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the converter can be told apart with errors.Is (and
+// errors.As for MissingGlyphsError); their messages keep the details. A
+// cancelled conversion returns the context's error.
+var (
+	// ErrFontUnreadable: the font file could not be read.
+	ErrFontUnreadable = errors.New("reading font file")
+	// ErrFontInvalid: the file is not a usable font, or one of its tables
+	// is corrupt.
+	ErrFontInvalid = errors.New("invalid font")
+	// ErrInvalidOptions: an option is out of range, unknown, or can't be
+	// combined with another (or with this font, e.g. a missing axis).
+	ErrInvalidOptions = errors.New("invalid options")
+	// ErrEmbeddingRestricted: the font license forbids embedding and
+	// AllowRestricted is not set.
+	ErrEmbeddingRestricted = errors.New("font license forbids embedding")
+	// ErrTextureTooLarge: the glyphs don't fit the texture budget.
+	ErrTextureTooLarge = errors.New("glyphs do not fit the texture budget")
+)
+
+// MissingGlyphsError is returned when the font has none of the requested
+// characters, leaving nothing to put in the atlas.
+type MissingGlyphsError struct {
+	Chars string // The requested characters
+}
+
+func (e *MissingGlyphsError) Error() string {
+	return fmt.Sprintf("font has no glyphs for any of %q", e.Chars)
+}
+
+// kindError gives an error the kind of one of the sentinels above, for
+// errors.Is, without changing its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// withKind tags err with kind (nil stays nil, and errors that already have
+// a kind keep it).
+func withKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, k := range []error{ErrFontUnreadable, ErrFontInvalid, ErrInvalidOptions, ErrEmbeddingRestricted, ErrTextureTooLarge} {
+		if errors.Is(err, k) {
+			return err
+		}
+	}
+	return &kindError{kind: kind, err: err}
+}
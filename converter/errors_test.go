@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestErrorKinds(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	garbage := writeTempFont(t, "garbage.ttf", []byte("not a font at all, just some bytes"))
+
+	restrictedData := append([]byte(nil), goregular.TTF...)
+	numTables := int(binary.BigEndian.Uint16(restrictedData[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := restrictedData[12+16*i:]
+		if string(rec[:4]) == "OS/2" {
+			binary.BigEndian.PutUint16(restrictedData[binary.BigEndian.Uint32(rec[8:])+8:], 0x0002)
+		}
+	}
+	restricted := writeTempFont(t, "restricted.ttf", restrictedData)
+
+	base := Options{Size: 16, Chars: "ABC", Format: "png"}
+	with := func(change func(*Options)) Options {
+		opts := base
+		change(&opts)
+		return opts
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		opts Options
+		kind error
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "nope.ttf"), base, ErrFontUnreadable},
+		{"garbage", garbage, base, ErrFontInvalid},
+		{"face index", path, with(func(o *Options) { o.FaceIndex = 2 }), ErrInvalidOptions},
+		{"gamma", path, with(func(o *Options) { o.Gamma = 20 }), ErrInvalidOptions},
+		{"size", path, with(func(o *Options) { o.Size = -1 }), ErrInvalidOptions},
+		{"instance", path, with(func(o *Options) { o.NamedInstance = "Bold" }), ErrInvalidOptions},
+		{"charset", path, with(func(o *Options) { o.Chars, o.Charset = "漢", "windows-1252" }), ErrInvalidOptions},
+		{"restricted", restricted, base, ErrEmbeddingRestricted},
+		{"budget", path, with(func(o *Options) { o.Size, o.FitWidth, o.FitHeight, o.Spacing = 0, 2, 2, [2]int{2, 2} }), ErrTextureTooLarge},
+	} {
+		_, err := GenerateWithStats(tc.path, filepath.Join(t.TempDir(), "font"), tc.opts)
+		if !errors.Is(err, tc.kind) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.kind)
+		}
+		for _, other := range []error{ErrFontUnreadable, ErrFontInvalid, ErrInvalidOptions, ErrEmbeddingRestricted, ErrTextureTooLarge} {
+			if other != tc.kind && errors.Is(err, other) {
+				t.Errorf("%s: %v is also %v", tc.name, err, other)
+			}
+		}
+	}
+
+	// Kinds don't hide the cause or change the message.
+	_, err := GenerateWithStats(filepath.Join(t.TempDir(), "nope.ttf"), filepath.Join(t.TempDir(), "font"), base)
+	if !errors.Is(err, fs.ErrNotExist) || !strings.HasPrefix(err.Error(), "reading font file: open ") {
+		t.Errorf("missing file: %v", err)
+	}
+	_, err = GenerateWithStats(path, filepath.Join(t.TempDir(), "font"), with(func(o *Options) { o.Gamma = 20 }))
+	if err.Error() != "gamma must be between 0 and 10" {
+		t.Errorf("gamma: message %q changed", err)
+	}
+
+	// A font without any of the characters has nothing to lay out.
+	var missing *MissingGlyphsError
+	_, err = GenerateWithStats(path, filepath.Join(t.TempDir(), "font"), with(func(o *Options) { o.Chars = "漢字" }))
+	if !errors.As(err, &missing) || missing.Chars != "漢字" {
+		t.Errorf("missing glyphs: err = %v, want a MissingGlyphsError for %q", err, "漢字")
+	}
+
+	// One that has some of them leaves the others out and lists them.
+	stats, err := GenerateWithStats(path, filepath.Join(t.TempDir(), "font"), with(func(o *Options) { o.Chars = "A漢B字" }))
+	if err != nil || stats.Glyphs != 2 || stats.Missing != "漢字" {
+		t.Errorf("partly missing glyphs: %d glyphs, missing %q, err %v; want 2, %q", stats.Glyphs, stats.Missing, err, "漢字")
+	}
+}
@@ -0,0 +1,273 @@
+package converter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// isCollection reports whether data starts with the 'ttcf' signature used by
+// TrueType/OpenType collections (.ttc/.otc).
+func isCollection(data []byte) bool {
+	return len(data) >= 4 && binary.BigEndian.Uint32(data) == 0x74746366 // "ttcf"
+}
+
+// readFontFile reads the raw sfnt bytes of the font file at path.
+// WOFF and WOFF2 web fonts are decoded to sfnt in memory.
+func readFontFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFontUnreadable, err)
+	}
+	if isWOFF(data) {
+		if data, err = decodeWOFF(data); err != nil {
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("decoding web font: %w", err))
+		}
+	}
+	return data, nil
+}
+
+// FontExtensions lists the file extensions recognised as font files.
+var FontExtensions = []string{".ttf", ".otf", ".ttc", ".otc", ".woff", ".woff2"}
+
+// IsFontFile reports whether path has one of the FontExtensions.
+func IsFontFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range FontExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFace parses face 'index' from data.
+// Plain .ttf/.otf files only have face 0; collections may have several.
+func parseFace(data []byte, index int) (*opentype.Font, error) {
+	if !isCollection(data) {
+		if index != 0 {
+			return nil, withKind(ErrInvalidOptions, fmt.Errorf("face index %d out of range (font has 1 face)", index))
+		}
+		f, err := opentype.Parse(data)
+		if err != nil {
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font: %w", err))
+		}
+		return f, nil
+	}
+
+	c, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font collection: %w", err))
+	}
+	if index < 0 || index >= c.NumFonts() {
+		return nil, withKind(ErrInvalidOptions, fmt.Errorf("face index %d out of range (collection has %d faces)", index, c.NumFonts()))
+	}
+	f, err := c.Font(index)
+	if err != nil {
+		return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font: %w", err))
+	}
+	return f, nil
+}
+
+// FaceNames returns a file-name friendly name for every face in the font file.
+// Plain fonts yield a single entry; collections yield one entry per face.
+// Names come from the PostScript name (falling back to the full name) in each
+// face's name table, and are empty when the table has neither.
+func FaceNames(path string) ([]string, error) {
+	data, err := readFontFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	count := 1
+	if isCollection(data) {
+		c, err := opentype.ParseCollection(data)
+		if err != nil {
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font collection: %w", err))
+		}
+		count = c.NumFonts()
+	}
+
+	var buf sfnt.Buffer
+	names := make([]string, count)
+	for i := range names {
+		f, err := parseFace(data, i)
+		if err != nil {
+			return nil, err
+		}
+		name, err := f.Name(&buf, sfnt.NameIDPostScript)
+		if err != nil || name == "" {
+			name, _ = f.Name(&buf, sfnt.NameIDFull)
+		}
+		names[i] = SanitizeName(name)
+	}
+	return names, nil
+}
+
+// SanitizeName strips characters that are awkward in file names.
+func SanitizeName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// sfntTables maps table tags to the raw table data of a single face.
+type sfntTables map[uint32][]byte
+
+// readTables returns the raw tables of face 'index' in data (a plain font or
+// a collection). Table offsets in collections are relative to the file start.
+func readTables(data []byte, index int) (sfntTables, error) {
+	offset := 0
+	if isCollection(data) {
+		if len(data) < 12 {
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font collection: truncated header"))
+		}
+		numFonts := int(binary.BigEndian.Uint32(data[8:]))
+		if index < 0 || index >= numFonts || len(data) < 12+4*numFonts {
+			return nil, withKind(ErrInvalidOptions, fmt.Errorf("face index %d out of range (collection has %d faces)", index, numFonts))
+		}
+		offset = int(binary.BigEndian.Uint32(data[12+4*index:]))
+	}
+	if offset+12 > len(data) {
+		return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font: truncated table directory"))
+	}
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	if offset+12+16*numTables > len(data) {
+		return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font: truncated table directory"))
+	}
+
+	tables := make(sfntTables, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := data[offset+12+16*i:]
+		start := int(binary.BigEndian.Uint32(rec[8:]))
+		length := int(binary.BigEndian.Uint32(rec[12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("parsing font: table out of bounds"))
+		}
+		tables[binary.BigEndian.Uint32(rec)] = data[start : start+length]
+	}
+	return tables, nil
+}
+
+// FaceInfo describes a face as recorded in its name, OS/2 and head tables.
+type FaceInfo struct {
+	Family   string // Typographic family (name ID 16), else family (ID 1)
+	Style    string // Typographic subfamily (name ID 17), else subfamily (ID 2)
+	FullName string // Full name (name ID 4)
+	Bold     bool   // OS/2 fsSelection BOLD or head macStyle bold
+	Italic   bool   // OS/2 fsSelection ITALIC/OBLIQUE or head macStyle italic
+
+	FsType    uint16    // Raw OS/2 fsType
+	Embedding Embedding // Embedding permission derived from FsType
+}
+
+// Embedding is the font embedding permission declared by the OS/2 fsType
+// field, from most to least permissive.
+type Embedding int
+
+const (
+	EmbeddingInstallable  Embedding = iota // No restrictions
+	EmbeddingEditable                      // Embeddable, may be edited
+	EmbeddingPreviewPrint                  // Embeddable for preview & print only
+	EmbeddingRestricted                    // Must not be embedded
+)
+
+func (e Embedding) String() string {
+	switch e {
+	case EmbeddingEditable:
+		return "editable"
+	case EmbeddingPreviewPrint:
+		return "preview & print"
+	case EmbeddingRestricted:
+		return "restricted"
+	}
+	return "installable"
+}
+
+// embeddingFromFsType maps fsType to the least restrictive permission it
+// grants. Apps are told to honour the least restrictive bit when several
+// usage bits are set.
+func embeddingFromFsType(fsType uint16) Embedding {
+	switch {
+	case fsType&0x000f == 0:
+		return EmbeddingInstallable
+	case fsType&0x0008 != 0:
+		return EmbeddingEditable
+	case fsType&0x0004 != 0:
+		return EmbeddingPreviewPrint
+	case fsType&0x0002 != 0:
+		return EmbeddingRestricted
+	}
+	return EmbeddingInstallable
+}
+
+// DisplayName is the name written to the FNT face attribute: the full name,
+// else "family style".
+func (fi FaceInfo) DisplayName() string {
+	if fi.FullName != "" {
+		return fi.FullName
+	}
+	return strings.TrimSpace(fi.Family + " " + fi.Style)
+}
+
+// ReadFaceInfo reads the names and style flags of face 'index' of the font at path.
+func ReadFaceInfo(path string, index int) (FaceInfo, error) {
+	data, err := readFontFile(path)
+	if err != nil {
+		return FaceInfo{}, err
+	}
+	f, err := parseFace(data, index)
+	if err != nil {
+		return FaceInfo{}, err
+	}
+	tables, err := readTables(data, index)
+	if err != nil {
+		return FaceInfo{}, err
+	}
+	return faceInfo(f, tables), nil
+}
+
+// faceInfo collects the FaceInfo of a parsed face.
+func faceInfo(f *opentype.Font, tables sfntTables) FaceInfo {
+	var buf sfnt.Buffer
+	name := func(ids ...sfnt.NameID) string {
+		for _, id := range ids {
+			if s, err := f.Name(&buf, id); err == nil && strings.TrimSpace(s) != "" {
+				return strings.TrimSpace(s)
+			}
+		}
+		return ""
+	}
+
+	fi := FaceInfo{
+		Family:   name(sfnt.NameIDTypographicFamily, sfnt.NameIDFamily),
+		Style:    name(sfnt.NameIDTypographicSubfamily, sfnt.NameIDSubfamily),
+		FullName: name(sfnt.NameIDFull),
+	}
+
+	if os2 := tables[tagOf("OS/2")]; len(os2) >= 10 {
+		fi.FsType = binary.BigEndian.Uint16(os2[8:])
+		fi.Embedding = embeddingFromFsType(fi.FsType)
+	}
+	if os2 := tables[tagOf("OS/2")]; len(os2) >= 64 {
+		fsSelection := binary.BigEndian.Uint16(os2[62:])
+		fi.Italic = fsSelection&0x0001 != 0 || fsSelection&0x0200 != 0 // ITALIC, OBLIQUE
+		fi.Bold = fsSelection&0x0020 != 0
+	}
+	if head := tables[tagOf("head")]; len(head) >= 46 {
+		macStyle := binary.BigEndian.Uint16(head[44:])
+		fi.Bold = fi.Bold || macStyle&0x0001 != 0
+		fi.Italic = fi.Italic || macStyle&0x0002 != 0
+	}
+	return fi
+}
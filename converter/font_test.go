@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// buildCollection packs plain sfnt fonts into a .ttc, rebasing each font's
+// table offsets onto its position in the collection.
+func buildCollection(fonts ...[]byte) []byte {
+	header := 12 + 4*len(fonts)
+	out := make([]byte, header)
+	copy(out, "ttcf")
+	binary.BigEndian.PutUint32(out[4:], 0x00010000)
+	binary.BigEndian.PutUint32(out[8:], uint32(len(fonts)))
+
+	for i, src := range fonts {
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+		base := len(out)
+		binary.BigEndian.PutUint32(out[12+4*i:], uint32(base))
+
+		font := append([]byte(nil), src...)
+		numTables := int(binary.BigEndian.Uint16(font[4:]))
+		for t := 0; t < numTables; t++ {
+			rec := font[12+16*t:]
+			binary.BigEndian.PutUint32(rec[8:], binary.BigEndian.Uint32(rec[8:])+uint32(base))
+		}
+		out = append(out, font...)
+	}
+	return out
+}
+
+func writeTempFont(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCollectionFaces(t *testing.T) {
+	path := writeTempFont(t, "go.ttc", buildCollection(goregular.TTF, gobold.TTF))
+
+	names, err := FaceNames(path)
+	if err != nil {
+		t.Fatalf("FaceNames() failed: %v", err)
+	}
+	if len(names) != 2 || names[0] != "GoRegular" || names[1] != "Go-Bold" {
+		t.Errorf("FaceNames() = %q, want [GoRegular Go-Bold]", names)
+	}
+
+	outPrefix := filepath.Join(t.TempDir(), "bold")
+	opts := Options{Size: 16, Chars: "AB", Format: "png", Spacing: [2]int{2, 2}, Hinting: "full", FaceIndex: 1}
+	if err := GenerateWithOptions(path, outPrefix, opts); err != nil {
+		t.Fatalf("GenerateWithOptions() failed: %v", err)
+	}
+
+	opts.FaceIndex = 2
+	if err := GenerateWithOptions(path, outPrefix, opts); err == nil {
+		t.Error("expected an error for an out-of-range face index")
+	}
+}
+
+func TestReadFaceInfo(t *testing.T) {
+	path := writeTempFont(t, "Go-Bold-Italic.ttf", gobolditalic.TTF)
+
+	info, err := ReadFaceInfo(path, 0)
+	if err != nil {
+		t.Fatalf("ReadFaceInfo() failed: %v", err)
+	}
+	want := FaceInfo{Family: "Go", Style: "Bold Italic", FullName: "Go Bold Italic", Bold: true, Italic: true}
+	if info != want {
+		t.Errorf("ReadFaceInfo() = %+v, want %+v", info, want)
+	}
+
+	outPrefix := filepath.Join(t.TempDir(), "bi")
+	opts := Options{Size: 16, Chars: "A", Format: "png", Spacing: [2]int{2, 2}, Hinting: "full"}
+	if err := GenerateWithOptions(path, outPrefix, opts); err != nil {
+		t.Fatalf("GenerateWithOptions() failed: %v", err)
+	}
+	fnt, err := os.ReadFile(outPrefix + ".fnt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(fnt), `info face="Go Bold Italic" size=16 bold=1 italic=1 `) {
+		t.Errorf("unexpected info line: %s", strings.SplitN(string(fnt), "\n", 2)[0])
+	}
+}
+
+func TestRestrictedEmbedding(t *testing.T) {
+	// Patch Go Regular's OS/2 fsType to "restricted license embedding".
+	data := append([]byte(nil), goregular.TTF...)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := data[12+16*i:]
+		if string(rec[:4]) == "OS/2" {
+			binary.BigEndian.PutUint16(data[binary.BigEndian.Uint32(rec[8:])+8:], 0x0002)
+		}
+	}
+	path := writeTempFont(t, "restricted.ttf", data)
+
+	info, err := ReadFaceInfo(path, 0)
+	if err != nil {
+		t.Fatalf("ReadFaceInfo() failed: %v", err)
+	}
+	if info.FsType != 0x0002 || info.Embedding != EmbeddingRestricted {
+		t.Errorf("fsType=0x%04x embedding=%v, want 0x0002 restricted", info.FsType, info.Embedding)
+	}
+
+	outPrefix := filepath.Join(t.TempDir(), "restricted")
+	opts := Options{Size: 16, Chars: "A", Format: "png", Spacing: [2]int{2, 2}, Hinting: "full"}
+	if err := GenerateWithOptions(path, outPrefix, opts); err == nil {
+		t.Error("expected an error for a restricted font")
+	}
+	opts.AllowRestricted = true
+	if err := GenerateWithOptions(path, outPrefix, opts); err != nil {
+		t.Errorf("GenerateWithOptions() with AllowRestricted failed: %v", err)
+	}
+
+	for fsType, want := range map[uint16]Embedding{
+		0x0000: EmbeddingInstallable,
+		0x0004: EmbeddingPreviewPrint,
+		0x000c: EmbeddingEditable, // Least restrictive bit wins
+		0x0302: EmbeddingRestricted,
+	} {
+		if got := embeddingFromFsType(fsType); got != want {
+			t.Errorf("embeddingFromFsType(0x%04x) = %v, want %v", fsType, got, want)
+		}
+	}
+}
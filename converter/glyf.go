@@ -0,0 +1,261 @@
+package converter
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Minimal reader for TrueType (glyf/loca) outlines. sfnt.Font only hands out
+// finished segments, but variable fonts need the raw points so that gvar
+// deltas can be applied before the outline is built.
+
+var errInvalidGlyf = errors.New("invalid glyf data")
+
+// Composite glyph component flags.
+const (
+	compArgsAreWords   = 0x0001
+	compArgsAreXY      = 0x0002
+	compHaveScale      = 0x0008
+	compMoreComponents = 0x0020
+	compHaveXYScale    = 0x0040
+	compHaveTwoByTwo   = 0x0080
+	compScaledOffset   = 0x0800
+)
+
+// glyfTable gives access to individual glyph records.
+type glyfTable struct {
+	glyf, loca []byte
+	longLoca   bool
+	numGlyphs  int
+}
+
+// newGlyfTable locates the glyf and loca tables of a TrueType-flavoured font.
+func newGlyfTable(tables sfntTables) (*glyfTable, error) {
+	glyf, loca := tables[tagOf("glyf")], tables[tagOf("loca")]
+	head, maxp := tables[tagOf("head")], tables[tagOf("maxp")]
+	if glyf == nil || loca == nil || len(head) < 54 || len(maxp) < 6 {
+		return nil, errInvalidGlyf
+	}
+	t := &glyfTable{
+		glyf:      glyf,
+		loca:      loca,
+		longLoca:  binary.BigEndian.Uint16(head[50:]) != 0,
+		numGlyphs: int(binary.BigEndian.Uint16(maxp[4:])),
+	}
+	if (t.longLoca && len(loca) < 4*(t.numGlyphs+1)) || (!t.longLoca && len(loca) < 2*(t.numGlyphs+1)) {
+		return nil, errInvalidGlyf
+	}
+	return t, nil
+}
+
+// data returns the raw record of glyph g (empty for glyphs without outline).
+func (t *glyfTable) data(g int) ([]byte, error) {
+	if g < 0 || g >= t.numGlyphs {
+		return nil, errInvalidGlyf
+	}
+	var start, end int
+	if t.longLoca {
+		start, end = int(binary.BigEndian.Uint32(t.loca[4*g:])), int(binary.BigEndian.Uint32(t.loca[4*g+4:]))
+	} else {
+		start, end = 2*int(binary.BigEndian.Uint16(t.loca[2*g:])), 2*int(binary.BigEndian.Uint16(t.loca[2*g+2:]))
+	}
+	if start > end || end > len(t.glyf) {
+		return nil, errInvalidGlyf
+	}
+	return t.glyf[start:end], nil
+}
+
+// glyfComponent is one component of a composite glyph.
+type glyfComponent struct {
+	glyph  int
+	flags  uint16
+	dx, dy int        // Offset (or matching point numbers without compArgsAreXY)
+	matrix [4]float64 // xx, xy, yx, yy as stored in the font
+}
+
+// glyfGlyph is a parsed glyph record: either a simple glyph (contours) or a
+// composite glyph (components).
+type glyfGlyph struct {
+	bbox       [4]int16
+	endPts     []int
+	points     []glyfPoint
+	components []glyfComponent
+}
+
+// parseGlyph decodes a glyph record returned by glyfTable.data.
+func parseGlyph(data []byte) (glyfGlyph, error) {
+	var g glyfGlyph
+	if len(data) == 0 {
+		return g, nil
+	}
+	if len(data) < 10 {
+		return g, errInvalidGlyf
+	}
+	contours := int(int16(binary.BigEndian.Uint16(data)))
+	for i := range g.bbox {
+		g.bbox[i] = int16(binary.BigEndian.Uint16(data[2+2*i:]))
+	}
+	r := &woffReader{b: data, pos: 10}
+	if contours < 0 {
+		return g, parseComposite(r, &g)
+	}
+	return g, parseSimple(r, &g, contours)
+}
+
+func parseSimple(r *woffReader, g *glyfGlyph, contours int) error {
+	const (
+		onCurve    = 0x01
+		xShort     = 0x02
+		yShort     = 0x04
+		repeat     = 0x08
+		xSameOrPos = 0x10
+		ySameOrPos = 0x20
+	)
+
+	g.endPts = make([]int, contours)
+	for i := range g.endPts {
+		e, err := r.u16()
+		if err != nil {
+			return err
+		}
+		g.endPts[i] = int(e)
+		if i > 0 && g.endPts[i] <= g.endPts[i-1] {
+			return errInvalidGlyf
+		}
+	}
+	if contours == 0 {
+		return nil
+	}
+	instrLen, err := r.u16()
+	if err != nil {
+		return err
+	}
+	if _, err := r.bytes(int(instrLen)); err != nil {
+		return err
+	}
+
+	numPoints := g.endPts[contours-1] + 1
+	flags := make([]byte, 0, numPoints)
+	for len(flags) < numPoints {
+		f, err := r.u8()
+		if err != nil {
+			return err
+		}
+		flags = append(flags, f)
+		if f&repeat != 0 {
+			n, err := r.u8()
+			if err != nil {
+				return err
+			}
+			for ; n > 0 && len(flags) < numPoints; n-- {
+				flags = append(flags, f)
+			}
+		}
+	}
+
+	g.points = make([]glyfPoint, numPoints)
+	readCoords := func(short, sameOrPos byte, set func(p *glyfPoint, v int)) error {
+		v := 0
+		for i, f := range flags {
+			switch {
+			case f&short != 0:
+				d, err := r.u8()
+				if err != nil {
+					return err
+				}
+				if f&sameOrPos != 0 {
+					v += int(d)
+				} else {
+					v -= int(d)
+				}
+			case f&sameOrPos == 0:
+				d, err := r.u16()
+				if err != nil {
+					return err
+				}
+				v += int(int16(d))
+			}
+			set(&g.points[i], v)
+		}
+		return nil
+	}
+	if err := readCoords(xShort, xSameOrPos, func(p *glyfPoint, v int) { p.x = v }); err != nil {
+		return err
+	}
+	if err := readCoords(yShort, ySameOrPos, func(p *glyfPoint, v int) { p.y = v }); err != nil {
+		return err
+	}
+	for i, f := range flags {
+		g.points[i].onCurve = f&onCurve != 0
+	}
+	return nil
+}
+
+func parseComposite(r *woffReader, g *glyfGlyph) error {
+	f2dot14 := func() (float64, error) {
+		v, err := r.u16()
+		return float64(int16(v)) / 16384, err
+	}
+
+	for {
+		flags, err := r.u16()
+		if err != nil {
+			return err
+		}
+		glyph, err := r.u16()
+		if err != nil {
+			return err
+		}
+		c := glyfComponent{glyph: int(glyph), flags: flags, matrix: [4]float64{1, 0, 0, 1}}
+
+		if flags&compArgsAreWords != 0 {
+			a, err1 := r.u16()
+			b, err2 := r.u16()
+			if err := errors.Join(err1, err2); err != nil {
+				return err
+			}
+			if flags&compArgsAreXY != 0 {
+				c.dx, c.dy = int(int16(a)), int(int16(b))
+			} else {
+				c.dx, c.dy = int(a), int(b)
+			}
+		} else {
+			a, err1 := r.u8()
+			b, err2 := r.u8()
+			if err := errors.Join(err1, err2); err != nil {
+				return err
+			}
+			if flags&compArgsAreXY != 0 {
+				c.dx, c.dy = int(int8(a)), int(int8(b))
+			} else {
+				c.dx, c.dy = int(a), int(b)
+			}
+		}
+
+		switch {
+		case flags&compHaveScale != 0:
+			s, err := f2dot14()
+			if err != nil {
+				return err
+			}
+			c.matrix[0], c.matrix[3] = s, s
+		case flags&compHaveXYScale != 0:
+			for _, i := range []int{0, 3} {
+				if c.matrix[i], err = f2dot14(); err != nil {
+					return err
+				}
+			}
+		case flags&compHaveTwoByTwo != 0:
+			for i := range c.matrix {
+				if c.matrix[i], err = f2dot14(); err != nil {
+					return err
+				}
+			}
+		}
+
+		g.components = append(g.components, c)
+		if flags&compMoreComponents == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// atlasLayout holds the rasterised glyphs of one size and their places in
+// the atlas. Every glyph bitmap is a full line high; its width is the
+// advance plus any oblique overhang.
+type atlasLayout struct {
+	pointSize  float64
+	ppem       float64
+	ascent     int
+	lineHeight int
+	overhangL  int    // Bitmap pixels left of the pen position
+	overhangR  int    // Bitmap pixels right of the advance
+	padding    [4]int // Empty pixels around each bitmap: up, right, down, left
+	antialias  bool
+	cellWidth  int    // Fixed cell width (monospace), 0 otherwise
+	overflow   []rune // Glyphs wider than their fixed cell
+	missing    []rune // Characters the font has no glyph for
+	cells      []glyphCell
+	tiles      []glyphTile // Distinct bitmaps; identical glyphs share one
+	width      int
+	height     int
+}
+
+// glyphCell is one character of the FNT.
+type glyphCell struct {
+	char     rune
+	tile     int // Index into atlasLayout.tiles
+	xadvance int
+}
+
+// glyphTile is a rasterised glyph bitmap and its place in the atlas. x and y
+// are the top left of its rectangle, which includes the padding.
+type glyphTile struct {
+	img   *image.RGBA
+	x, y  int
+	width int // Bitmap width, without the padding
+}
+
+// rectSize returns the size of a tile's atlas rectangle, padding included.
+func (lay *atlasLayout) rectSize(t glyphTile) (w, h int) {
+	p := lay.padding
+	return p[3] + t.width + p[1], p[0] + lay.lineHeight + p[2]
+}
+
+// validateSpacing checks the padding (up, right, down, left) and spacing
+// (horizontal, vertical).
+func validateSpacing(padding [4]int, spacing [2]int) error {
+	for _, v := range padding {
+		if v < 0 {
+			return fmt.Errorf("padding cannot be negative")
+		}
+	}
+	if spacing[0] < 0 || spacing[1] < 0 {
+		return fmt.Errorf("spacing cannot be negative")
+	}
+	return nil
+}
+
+// layout creates the face for size (in SizeMode units), rasterises every
+// available glyph and places the distinct bitmaps: in a single row, or in
+// rows filling the texture budget. It fails with ErrTextureTooLarge if the
+// glyphs don't fit the budget, and with the context's error once it is
+// cancelled.
+func (g *generator) layout(tr *tracker, size float64) (lay *atlasLayout, err error) {
+	opts := g.opts
+	if err := validateSize(size, opts.DPI, opts.SizeMode); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+
+	// Pixel sizes are solved for the point size that matches them.
+	pointSize := size
+	if opts.SizeMode != SizePoints {
+		if pointSize, err = solveSize(g.plainFace, opts.SizeMode, size, g.dpi); err != nil {
+			return nil, fmt.Errorf("sizing font: %w", err)
+		}
+	}
+
+	face, err := g.newFace(pointSize, pointSize)
+	if err != nil {
+		return nil, fmt.Errorf("creating face: %w", err)
+	}
+	defer func() {
+		if cerr := face.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	metrics := face.Metrics()
+	lay = &atlasLayout{
+		pointSize:  pointSize,
+		ppem:       pointSize * g.dpi / 72,
+		ascent:     metrics.Ascent.Ceil(),
+		lineHeight: metrics.Height.Ceil(),
+		padding:    opts.Padding,
+	}
+
+	// Oblique glyphs spill out of their advance; bitmaps are widened by the
+	// overhang (xoffset moves them left of the pen position).
+	lay.overhangL, lay.overhangR = obliqueOverhang(opts.Oblique, lay.ascent, lay.lineHeight-lay.ascent)
+
+	if lay.antialias, err = resolveAntialias(opts.Antialias, g.f, opts.Chars, lay.ppem); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+
+	hiFace, err := g.newHiFace(pointSize)
+	if err != nil {
+		return nil, fmt.Errorf("creating face: %w", err)
+	}
+	if hiFace != nil {
+		defer func() {
+			if cerr := hiFace.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+	}
+
+	var glyphs []glyphMetrics
+	for _, char := range opts.Chars {
+		bounds, advance, ok := face.GlyphBounds(char)
+		if !ok {
+			lay.missing = append(lay.missing, char)
+			continue
+		}
+		m := glyphMetrics{char: char, advance: advance.Ceil()}
+		if !bounds.Empty() {
+			m.inkMin, m.inkMax = bounds.Min.X.Floor(), bounds.Max.X.Ceil()
+		}
+		glyphs = append(glyphs, m)
+	}
+	if len(glyphs) == 0 {
+		return nil, &MissingGlyphsError{Chars: opts.Chars}
+	}
+
+	// Fixed cells share one advance: the widest one unless set explicitly.
+	cellWidth := opts.CellWidth
+	if opts.Monospace != "" && cellWidth == 0 {
+		for _, m := range glyphs {
+			cellWidth = max(cellWidth, m.advance)
+		}
+	}
+	lay.cellWidth = cellWidth
+
+	placed := make([]placedGlyph, len(glyphs))
+	for i, m := range glyphs {
+		xadvance, penX := m.advance, 0
+		if opts.Monospace != "" {
+			var overflow bool
+			xadvance = cellWidth
+			if penX, overflow = placeInCell(m, cellWidth, opts.Monospace, lay.overhangL, lay.overhangR); overflow {
+				lay.overflow = append(lay.overflow, m.char)
+			}
+		}
+		placed[i] = placedGlyph{
+			char:     m.char,
+			xadvance: xadvance,
+			width:    lay.overhangL + xadvance + lay.overhangR,
+			dotX:     lay.overhangL + penX,
+		}
+	}
+
+	imgs, err := g.rasterizeAll(tr, size, lay, face, hiFace, placed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical bitmaps (e.g. Latin A and Greek Alpha, or the various
+	// spaces) are packed once.
+	seen := make(map[tileKey]int)
+	for i, img := range imgs {
+		key := newTileKey(img)
+		tile, ok := seen[key]
+		if !ok {
+			tile = len(lay.tiles)
+			seen[key] = tile
+			lay.tiles = append(lay.tiles, glyphTile{img: img, width: img.Rect.Dx()})
+		}
+		lay.cells = append(lay.cells, glyphCell{char: placed[i].char, tile: tile, xadvance: placed[i].xadvance})
+	}
+
+	if opts.FitWidth > 0 || opts.FitHeight > 0 {
+		if !packRows(lay, opts.FitWidth, opts.FitHeight, opts.Spacing) {
+			return nil, ErrTextureTooLarge
+		}
+	} else {
+		packRows(lay, 0, 0, opts.Spacing)
+	}
+	tr.report(StagePack, size, len(lay.tiles), len(lay.tiles))
+	return lay, nil
+}
+
+// rasterize renders char into a new width x lineHeight bitmap with the pen
+// dotX pixels in, on the baseline.
+func (g *generator) rasterize(lay *atlasLayout, face, hiFace font.Face, char rune, width, dotX int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, lay.lineHeight))
+	switch {
+	case g.opts.LCD != "":
+		drawLCD(img, hiFace, char, 0, 0, dotX, width, lay.lineHeight, lay.ascent, g.opts.LCD)
+	case hiFace != nil:
+		drawSupersampled(img, hiFace, char, 0, 0, dotX, width, lay.lineHeight, lay.ascent, g.opts.Supersample, g.opts.SupersampleFilter)
+	default:
+		// CRITICAL FIX: Explicitly set the Dot to the exact integer position.
+		// This prevents sub-pixel accumulation errors (drifting) and ensures
+		// the image pixels align 1:1 with the FNT coordinates.
+		drawer := &font.Drawer{
+			Dst:  img,
+			Src:  image.White,
+			Face: face,
+			Dot:  fixed.P(dotX, lay.ascent),
+		}
+		drawer.DrawString(string(char))
+	}
+	return img
+}
+
+// packRows places the tile rectangles left to right, starting a new row when
+// the next one would cross maxWidth (0: a single row). Rectangles are
+// separated by the horizontal spacing and rows by the vertical spacing.
+// Without a budget the atlas is as large as the rectangles (including the
+// trailing horizontal spacing); with one it is exactly maxWidth x maxHeight
+// (0: unbounded) and packRows reports whether everything fits.
+func packRows(lay *atlasLayout, maxWidth, maxHeight int, spacing [2]int) bool {
+	_, rowHeight := lay.rectSize(glyphTile{}) // Every rectangle is a padded line high
+	x, y, right := 0, 0, 0
+	for i := range lay.tiles {
+		t := &lay.tiles[i]
+		w, _ := lay.rectSize(*t)
+		if maxWidth > 0 && x > 0 && x+w > maxWidth {
+			x, y = 0, y+rowHeight+spacing[1]
+		}
+		t.x, t.y = x, y
+		x += w + spacing[0]
+		right = max(right, x)
+	}
+
+	lay.width, lay.height = right, y+rowHeight
+	fits := true
+	if maxWidth > 0 {
+		for _, t := range lay.tiles {
+			w, _ := lay.rectSize(t)
+			fits = fits && t.x+w <= maxWidth
+		}
+		lay.width = maxWidth
+	}
+	if maxHeight > 0 {
+		fits = fits && y+rowHeight <= maxHeight
+		lay.height = maxHeight
+	}
+	return fits
+}
+
+// maxFitSize bounds the fit search (in SizeMode units).
+const maxFitSize = 4096
+
+// fit finds the largest whole size (in SizeMode units) whose glyphs pack
+// into the texture budget. Larger sizes never pack better, so it doubles
+// until the glyphs stop fitting and then bisects.
+func (g *generator) fit(tr *tracker) (float64, error) {
+	fits := func(size int) (bool, error) {
+		_, err := g.layout(tr, float64(size))
+		if errors.Is(err, ErrTextureTooLarge) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	ok, err := fits(1)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, withKind(ErrTextureTooLarge, fmt.Errorf("glyphs do not fit %dx%d even at size 1", g.opts.FitWidth, g.opts.FitHeight))
+	}
+
+	lo, hi := 1, 2 // lo fits, hi is unknown until it fails
+	for {
+		if hi > maxFitSize {
+			return maxFitSize, nil
+		}
+		if ok, err = fits(hi); err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if ok, err = fits(mid); err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return float64(lo), nil
+}
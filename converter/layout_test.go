@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestFitSize(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	opts := Options{
+		Chars:     "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		Format:    "png",
+		Spacing:   [2]int{1, 1},
+		FitWidth:  128,
+		FitHeight: 64,
+	}
+
+	size, err := FitSize(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := filepath.Join(t.TempDir(), "font")
+	if err := GenerateWithOptions(path, prefix, opts); err != nil {
+		t.Fatal(err)
+	}
+	fnt, _ := os.ReadFile(prefix + ".fnt")
+	if !strings.Contains(string(fnt), fmt.Sprintf(" size=%d ", int(size))) {
+		t.Errorf("fitted size %g not used:\n%s", size, fnt)
+	}
+	if !strings.Contains(string(fnt), "scaleW=128 scaleH=64 ") {
+		t.Errorf("atlas is not the budget size:\n%s", fnt)
+	}
+	if img := readPNG(t, prefix+".png"); img.Bounds().Dx() != 128 || img.Bounds().Dy() != 64 {
+		t.Errorf("image is %v, want 128x64", img.Bounds())
+	}
+
+	// Every cell lies inside the budget.
+	lines := 0
+	for _, line := range strings.Split(string(fnt), "\n") {
+		if !strings.HasPrefix(line, "char ") {
+			continue
+		}
+		lines++
+		var id, x, y, w, h int
+		fmt.Sscanf(line, "char id=%d x=%d y=%d width=%d height=%d", &id, &x, &y, &w, &h)
+		if x+w > 128 || y+h > 64 {
+			t.Errorf("cell outside the budget: %s", line)
+		}
+	}
+	if lines != len(opts.Chars) {
+		t.Errorf("%d chars, want %d", lines, len(opts.Chars))
+	}
+
+	// The next size up does not fit.
+	opts.Size = size + 1
+	if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "big"), opts); err == nil {
+		t.Errorf("size %g fits, want %g to be the largest", size+1, size)
+	}
+
+	// A budget too small for even the smallest size is an error.
+	opts.Size, opts.FitWidth, opts.FitHeight = 0, 2, 2
+	if _, err := FitSize(path, opts); err == nil {
+		t.Error("expected an error for a 2x2 budget")
+	}
+}
+
+func TestPackRows(t *testing.T) {
+	lay := &atlasLayout{lineHeight: 10}
+	for _, w := range []int{6, 6, 6} {
+		lay.tiles = append(lay.tiles, glyphTile{width: w})
+	}
+
+	// Without a budget: one row, trailing spacing included.
+	if !packRows(lay, 0, 0, [2]int{1, 1}) || lay.width != 21 || lay.height != 10 {
+		t.Errorf("single row: %dx%d", lay.width, lay.height)
+	}
+
+	// Two tiles per row, rows separated by the spacing.
+	if !packRows(lay, 14, 21, [2]int{1, 1}) || lay.tiles[2].x != 0 || lay.tiles[2].y != 11 {
+		t.Errorf("wrapped: %+v", lay.tiles)
+	}
+	if lay.width != 14 || lay.height != 21 {
+		t.Errorf("budget atlas is %dx%d, want 14x21", lay.width, lay.height)
+	}
+	if packRows(lay, 14, 20, [2]int{1, 1}) {
+		t.Error("rows taller than the budget reported as fitting")
+	}
+}
+
+func TestPaddingAndSpacing(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	prefix := filepath.Join(t.TempDir(), "font")
+	opts := Options{Size: 24, Chars: "AB", Format: "png", Padding: [4]int{1, 2, 3, 4}, Spacing: [2]int{5, 6}}
+	if err := GenerateWithOptions(path, prefix, opts); err != nil {
+		t.Fatal(err)
+	}
+	fnt, _ := os.ReadFile(prefix + ".fnt")
+	lines := strings.Split(string(fnt), "\n")
+	if !strings.Contains(lines[0], " padding=1,2,3,4 spacing=5,6") {
+		t.Errorf("info line: %s", lines[0])
+	}
+
+	var lineHeight int
+	fmt.Sscanf(lines[1], "common lineHeight=%d", &lineHeight)
+	var x [2]int
+	var w, h, xoffset, yoffset, xadvance int
+	for i, line := range lines[4:6] {
+		var id, y int
+		fmt.Sscanf(line, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=%d xadvance=%d",
+			&id, &x[i], &y, &w, &h, &xoffset, &yoffset, &xadvance)
+	}
+	if h != 1+lineHeight+3 || w != 4+xadvance+2 || xoffset != -4 || yoffset != -1 {
+		t.Errorf("padded rectangle: %s", lines[5])
+	}
+	if x[1] != x[0]+w+5 {
+		t.Errorf("B at x=%d, want %d", x[1], x[0]+w+5)
+	}
+
+	// The padding stays empty.
+	img := readPNG(t, prefix+".png")
+	for py := 0; py < img.Bounds().Dy(); py++ {
+		for px := 0; px < img.Bounds().Dx(); px++ {
+			inGlyph := false
+			for _, cx := range x {
+				inGlyph = inGlyph || (px >= cx+4 && px < cx+w-2 && py >= 1 && py < h-3)
+			}
+			if _, _, _, a := img.At(px, py).RGBA(); a != 0 && !inGlyph {
+				t.Fatalf("ink outside the glyph bitmaps at (%d,%d)", px, py)
+			}
+		}
+	}
+
+	if err := GenerateWithOptions(path, prefix, Options{Size: 24, Chars: "A", Format: "png", Padding: [4]int{0, -1, 0, 0}}); err == nil {
+		t.Error("expected an error for negative padding")
+	}
+}
@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// LCD subpixel rendering rasterises glyph outlines stretched to three times
+// the horizontal resolution (the vertical one is unchanged), smooths the
+// subpixels with a FIR filter to limit colour fringes, and stores the
+// coverage of each subpixel in its own channel.
+
+// lcdFilter is FreeType's default LCD filter (weights out of 256).
+var lcdFilter = [5]int{8, 77, 86, 77, 8}
+
+// validateLCD checks the subpixel order and the options it can't be combined with.
+func validateLCD(order string, supersample int, antialias string) error {
+	switch order {
+	case "":
+		return nil
+	case "rgb", "bgr":
+	default:
+		return fmt.Errorf("unknown LCD subpixel order %q (use 'rgb' or 'bgr')", order)
+	}
+	if supersample > 1 {
+		return fmt.Errorf("LCD rendering cannot be combined with supersampling")
+	}
+	if antialias != "" && antialias != "on" {
+		return fmt.Errorf("LCD rendering requires antialiasing")
+	}
+	return nil
+}
+
+// lcdScale is how many subpixels make up a pixel.
+const lcdScale = 3
+
+// lcdFace draws the glyph outlines of a face stretched lcdScale times
+// horizontally, so that every pixel of its masks is one subpixel. Metrics
+// and bounds are those of the unstretched face.
+type lcdFace struct {
+	font.Face
+	outline func(r rune) (sfnt.Segments, bool) // At the unstretched size
+
+	rast vector.Rasterizer
+	mask image.Alpha
+}
+
+// newLCDFace creates the face drawLCD renders from, at pointSize, with any
+// synthetic styles stretched to match.
+func (g *generator) newLCDFace(pointSize float64) (font.Face, error) {
+	face, err := g.plainFace(pointSize)
+	if err != nil {
+		return nil, err
+	}
+	lf := &lcdFace{Face: face}
+	if vf, ok := face.(*variableFace); ok {
+		lf.outline = func(r rune) (sfnt.Segments, bool) {
+			segs, _, ok := vf.glyph(r)
+			return segs, ok
+		}
+	} else {
+		ppem := fixed.Int26_6(0.5 + pointSize*g.dpi*64/72) // As opentype.NewFace
+		var buf sfnt.Buffer
+		lf.outline = func(r rune) (sfnt.Segments, bool) {
+			x, err := g.f.GlyphIndex(&buf, r)
+			if err != nil || x == 0 {
+				return nil, false
+			}
+			segs, err := g.f.LoadGlyph(&buf, x, ppem, nil)
+			return segs, err == nil
+		}
+	}
+	if g.opts.Embolden == 0 && g.opts.Oblique == 0 {
+		return lf, nil
+	}
+	// Stems widen, and rows shift, by lcdScale times as many subpixels.
+	return &syntheticFace{Face: lf, embolden: g.opts.Embolden * lcdScale, shear: lcdScale * math.Tan(g.opts.Oblique*math.Pi/180)}, nil
+}
+
+func (f *lcdFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	segs, ok := f.outline(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	stretched := make(sfnt.Segments, len(segs))
+	for i, seg := range segs {
+		for j := range seg.Args {
+			seg.Args[j].X *= lcdScale
+		}
+		stretched[i] = seg
+	}
+	dr, ok := rasterizeOutline(&f.rast, &f.mask, stretched, dot)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	advance, _ := f.Face.GlyphAdvance(r)
+	return dr, &f.mask, image.Point{}, advance * lcdScale, true
+}
+
+// drawLCD renders char with the stretched face lcdFace into the width x
+// height cell of dst at (x, y), with the pen dotX pixels into the cell. The
+// red, green and blue channels get the filtered coverage of the left,
+// middle and right subpixel (swapped for "bgr"); alpha is the highest of
+// the three. The values are coverage, not colour times alpha: atlasImage
+// writes them as they are.
+func drawLCD(dst *image.RGBA, lcdFace font.Face, char rune, x, y, dotX, width, height, ascent int, order string) {
+	subW := width * lcdScale
+	hi := image.NewAlpha(image.Rect(0, 0, subW, height))
+	d := &font.Drawer{
+		Dst:  hi,
+		Src:  image.White,
+		Face: lcdFace,
+		Dot:  fixed.P(dotX*lcdScale, ascent),
+	}
+	d.DrawString(string(char))
+
+	for py := 0; py < height; py++ {
+		sub := hi.Pix[py*hi.Stride : py*hi.Stride+subW]
+		filtered := func(sx int) uint8 {
+			sum := 0
+			for k, w := range lcdFilter {
+				if i := sx + k - len(lcdFilter)/2; i >= 0 && i < subW {
+					sum += int(sub[i]) * w
+				}
+			}
+			return uint8(min(255, (sum+128)/256))
+		}
+
+		for px := 0; px < width; px++ {
+			r, g, b := filtered(px*lcdScale), filtered(px*lcdScale+1), filtered(px*lcdScale+2)
+			if order == "bgr" {
+				r, b = b, r
+			}
+			a := max(r, g, b)
+			if a == 0 {
+				continue
+			}
+			dst.SetRGBA(x+px, y+py, color.RGBA{R: r, G: g, B: b, A: a})
+		}
+	}
+}
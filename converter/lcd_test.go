@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestLCD(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	render := func(order string) image.Image {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "lcd")
+		opts := Options{Size: 16, Chars: "Il", Format: "png", Spacing: [2]int{2, 2}, Hinting: "none", LCD: order}
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatalf("lcd=%s: %v", order, err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		if !strings.Contains(string(fnt), " lcd="+order+" ") {
+			t.Errorf("lcd=%s: info line does not record the mode", order)
+		}
+		return readPNG(t, prefix+".png")
+	}
+	rgb, bgr := render("rgb"), render("bgr")
+
+	// Stems must show colour fringes, and bgr must mirror rgb's channels.
+	colored := false
+	b := rgb.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := rgb.At(x, y).RGBA()
+			r2, g2, b2, a2 := bgr.At(x, y).RGBA()
+			if r1 != b2 || g1 != g2 || b1 != r2 || a1 != a2 {
+				t.Fatalf("(%d,%d): rgb %v, bgr %v are not mirrored", x, y, rgb.At(x, y), bgr.At(x, y))
+			}
+			if r1 != g1 || g1 != b1 {
+				colored = true
+			}
+			if a1 < max(r1, g1, b1) {
+				t.Fatalf("(%d,%d): alpha below channel coverage: %v", x, y, rgb.At(x, y))
+			}
+		}
+	}
+	if !colored {
+		t.Error("expected per-channel coverage in the atlas")
+	}
+
+	opts := Options{Size: 16, Chars: "I", Format: "png", LCD: "rgb", Supersample: 4}
+	if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "bad"), opts); err == nil {
+		t.Error("expected an error combining LCD with supersampling")
+	}
+}
+
+func TestLCDStemCoverage(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	// The bar '|' spans [left, right) pixels from the pen.
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 32, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds, _, _ := face.GlyphBounds('|')
+	left, right := float64(bounds.Min.X)/64, float64(bounds.Max.X)/64
+	midY := (bounds.Min.Y + bounds.Max.Y).Floor() / 2
+
+	// Whatever the alpha mode, the file stores the filtered coverage of each
+	// subpixel in R, G and B, and their maximum in A.
+	for _, tc := range []struct{ format, alpha string }{
+		{"png", ""},
+		{"png", AlphaStraight},
+		{"png", AlphaPremultiplied},
+		{"bmp", ""},
+		{"bmp", AlphaStraight},
+	} {
+		prefix := filepath.Join(t.TempDir(), "lcd")
+		opts := Options{Size: 32, Chars: "|", Format: tc.format, Hinting: "none", LCD: "rgb", Alpha: tc.alpha}
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatal(err)
+		}
+		var img *image.NRGBA
+		if tc.format == "png" {
+			var ok bool
+			if img, ok = readPNG(t, prefix+".png").(*image.NRGBA); !ok {
+				t.Fatalf("%s/%q: PNG does not decode to NRGBA", tc.format, tc.alpha)
+			}
+		} else {
+			img = readBMP(t, prefix+".bmp")
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		var x, y, w, h, xoff, base int
+		if _, err := fmt.Sscanf(charLine(t, string(fnt), '|'), "char id=124 x=%d y=%d width=%d height=%d xoffset=%d", &x, &y, &w, &h, &xoff); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range strings.Fields(string(fnt)) {
+			fmt.Sscanf(field, "base=%d", &base)
+		}
+		pen, row := float64(x-xoff), y+base+midY
+
+		// Subpixel s of the atlas row covers [s/3, (s+1)/3).
+		sub := func(s int) float64 {
+			lo, hi := max(float64(s)/3, pen+left), min(float64(s+1)/3, pen+right)
+			return max(0, hi-lo) * 3 * 255
+		}
+		fringe := false
+		for px := x; px < x+w; px++ {
+			var want [3]int
+			for c := range want {
+				sum := 0.0
+				for k, wt := range lcdFilter {
+					sum += sub(px*3+c+k-len(lcdFilter)/2) * float64(wt)
+				}
+				want[c] = min(255, int(math.Round(sum/256)))
+			}
+			got := img.NRGBAAt(px, row)
+			for c, v := range []uint8{got.R, got.G, got.B} {
+				if d := int(v) - want[c]; d < -3 || d > 3 {
+					t.Errorf("%s/%q: pixel %d stores %v, want coverage %v", tc.format, tc.alpha, px, got, want)
+					break
+				}
+			}
+			if got.A != max(got.R, got.G, got.B) {
+				t.Errorf("%s/%q: pixel %d alpha %d, want the highest channel", tc.format, tc.alpha, px, got.A)
+			}
+			if want[0] != want[2] {
+				fringe = true
+			}
+		}
+		if !fringe {
+			t.Errorf("%s/%q: the stem edges have no colour fringes to check", tc.format, tc.alpha)
+		}
+	}
+}
@@ -1,110 +1,440 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
-	"golang.org/x/image/math/fixed"
 )
 
+// Options controls how a font is rendered into a BMFont atlas.
+type Options struct {
+	Size      float64 // Points at DPI, or pixels in the line/cap SizeMode
+	Chars     string
+	Format    string // "png" or "bmp"
+	Padding   [4]int // Empty pixels around each glyph (up, right, down, left), inside its rectangle
+	Spacing   [2]int // Empty pixels between glyph rectangles (horizontal, vertical)
+	Hinting   string // "none", "vertical" or "full"
+	FaceIndex int    // Face to use from a .ttc/.otc collection (0 for plain fonts)
+
+	// Variable fonts: a named instance (e.g. "Bold Condensed") and/or explicit
+	// axis values (4-character tags, see ParseAxes). Both empty means the
+	// default instance. Only the glyph outlines (gvar) and advances (HVAR)
+	// vary: MVAR is not applied, so the ascent, descent and line height are
+	// those of the default instance.
+	NamedInstance string
+	Axes          []AxisValue
+
+	// AllowRestricted renders fonts whose OS/2 fsType forbids embedding.
+	AllowRestricted bool
+
+	// EmitMeta also writes <outPrefix>.json with the font's FontMeta.
+	EmitMeta bool
+
+	// Supersample renders glyphs at N times the size and filters them down
+	// with SupersampleFilter ("box" or "lanczos"). 0 or 1 disables it.
+	Supersample       int
+	SupersampleFilter string
+
+	// Antialias is "on" (default), "off" (1-bit glyphs, aa=0 smooth=0) or
+	// "auto" (off for pixel fonts rendered at a multiple of their grid).
+	// Without antialiasing, pixels with coverage >= Threshold are set
+	// (0 means 128).
+	Antialias string
+	Threshold int
+
+	// LCD enables subpixel rendering with the given stripe order ("rgb" or
+	// "bgr"): per-subpixel coverage goes to the atlas RGB channels.
+	LCD string
+
+	// Gamma (c^(1/Gamma)) and Contrast (S-curve) remap glyph coverage;
+	// 0 means 1 (unchanged). Alpha picks how the atlas stores alpha:
+	// AlphaPremultiplied, AlphaStraight or "" (straight PNG, premultiplied BMP).
+	Gamma    float64
+	Contrast float64
+	Alpha    string
+
+	// Synthetic styles: Embolden widens stems by that many pixels (at Size),
+	// Oblique slants glyphs by that many degrees (positive leans right).
+	Embolden float64
+	Oblique  float64
+
+	// DPI converts points to pixels (0 means 72, where 1pt = 1px).
+	// SizeMode SizeLineHeight or SizeCapHeight makes Size a target height in
+	// pixels and solves for the matching point size.
+	DPI      float64
+	SizeMode string
+
+	// FitWidth x FitHeight is a texture budget: glyphs are packed in rows
+	// into an atlas of exactly that size, failing if they don't fit. With
+	// Size 0, the largest whole size that fits is used (see FitSize).
+	FitWidth  int
+	FitHeight int
+
+	// Monospace puts every glyph in a cell of the same width and xadvance,
+	// aligned MonospaceLeft or MonospaceCenter ("" keeps proportional
+	// advances). CellWidth is the cell width in pixels (0: the widest advance).
+	Monospace string
+	CellWidth int
+
+	// Sheet renders the code page (SheetCP437) into a 16x16 grid of fixed
+	// cells instead of an atlas, ignoring Chars, padding and spacing, and
+	// writes no FNT. SheetListing also writes <outPrefix>.txt listing the code
+	// point of each tile. Monospace defaults to MonospaceCenter. SheetMap
+	// overrides the code point of tiles by index (see ReadSheetMap); U+0000
+	// leaves a tile blank.
+	Sheet        string
+	SheetListing bool
+	SheetMap     map[int]rune
+
+	// Charset writes char ids in a legacy code page (an IANA name such as
+	// "windows-1252", "ISO-8859-2" or "Shift_JIS") instead of Unicode code
+	// points; characters the code page lacks are left out.
+	Charset string
+
+	// Workers is how many goroutines rasterise glyphs (0: GOMAXPROCS).
+	// Small character sets always use one.
+	Workers int
+}
+
 // Generate creates the Font files (image + fnt).
 // Now accepts 'hinting' ("none", "vertical", "full")
+// padding is the spacing between glyphs (it predates Options.Padding).
 func Generate(fontPath string, size int, chars string, outPrefix string, format string, padding int, hinting string) (err error) {
-	// 1. Read & Parse Font
-	fontBytes, err := os.ReadFile(fontPath)
+	return GenerateWithOptions(fontPath, outPrefix, Options{
+		Size:    float64(size),
+		Chars:   chars,
+		Format:  format,
+		Spacing: [2]int{padding, padding},
+		Hinting: hinting,
+	})
+}
+
+// GenerateWithOptions creates the Font files (image + fnt) as described by opts.
+func GenerateWithOptions(fontPath string, outPrefix string, opts Options) error {
+	_, err := GenerateWithStats(fontPath, outPrefix, opts)
+	return err
+}
+
+// Stats describes a generated atlas.
+type Stats struct {
+	Glyphs      int      // Characters written to the FNT
+	Bitmaps     int      // Distinct glyph bitmaps packed into the atlas
+	SavedPixels int      // Atlas area not spent on duplicate bitmaps
+	Overflow    string   // Characters wider than their fixed cell (monospace)
+	Unmapped    string   // Characters left out because the charset lacks them
+	Missing     string   // Characters left out because the font has no glyph for them
+	Files       []string // Files written (image, FNT or sheet map, sidecar)
+}
+
+// GenerateWithStats is GenerateWithOptions, also reporting how many glyph
+// bitmaps were shared.
+func GenerateWithStats(fontPath string, outPrefix string, opts Options) (Stats, error) {
+	return GenerateContext(context.Background(), fontPath, outPrefix, opts, nil)
+}
+
+// GenerateContext is GenerateWithStats, reporting its progress to progress
+// (which may be nil) and giving up with ctx.Err() once ctx is cancelled.
+func GenerateContext(ctx context.Context, fontPath string, outPrefix string, opts Options, progress ProgressFunc) (Stats, error) {
+	f, err := OpenFont(fontPath, opts)
 	if err != nil {
-		return fmt.Errorf("reading font file: %w", err)
+		return Stats{}, err
 	}
-	f, err := opentype.Parse(fontBytes)
+	size := opts.Size
+	if size == 0 && (opts.FitWidth > 0 || opts.FitHeight > 0) {
+		if size, err = f.FitSizeContext(ctx, progress); err != nil {
+			return Stats{}, err
+		}
+	}
+	return f.GenerateContext(ctx, outPrefix, size, progress)
+}
+
+// FitSize returns the largest whole size (in opts.SizeMode units) whose
+// glyphs pack into the opts.FitWidth x opts.FitHeight texture budget.
+func FitSize(fontPath string, opts Options) (float64, error) {
+	f, err := OpenFont(fontPath, opts)
+	if err != nil {
+		return 0, err
+	}
+	return f.FitSize()
+}
+
+// Font is a font face parsed once, to be rendered at any number of sizes
+// with the same options. Its methods are safe for concurrent use; each call
+// creates its own faces and frees them when it returns, so only the parsed
+// font stays in memory between sizes.
+type Font struct {
+	g *generator
+}
+
+// OpenFont reads and parses a font (the face opts.FaceIndex of a collection)
+// and checks opts. opts.Size is ignored: every Generate call passes its own.
+func OpenFont(fontPath string, opts Options) (*Font, error) {
+	g, err := newGenerator(fontPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Font{g: g}, nil
+}
+
+// Info returns the names and embedding permission of the face.
+func (f *Font) Info() FaceInfo {
+	return f.g.info
+}
+
+// Generate creates the Font files (image + fnt) at size (in SizeMode units).
+func (f *Font) Generate(outPrefix string, size float64) (Stats, error) {
+	return f.GenerateContext(context.Background(), outPrefix, size, nil)
+}
+
+// GenerateContext is Generate, reporting its progress to progress (which may
+// be nil). Cancelling ctx stops it between glyphs with ctx.Err(); once the
+// files are being written, they are finished.
+func (f *Font) GenerateContext(ctx context.Context, outPrefix string, size float64, progress ProgressFunc) (Stats, error) {
+	if err := f.g.checkEmbedding(); err != nil {
+		return Stats{}, err
+	}
+	return f.g.generate(newTracker(ctx, progress), outPrefix, size)
+}
+
+// FitSize returns the largest whole size whose glyphs pack into the
+// FitWidth x FitHeight texture budget.
+func (f *Font) FitSize() (float64, error) {
+	return f.FitSizeContext(context.Background(), nil)
+}
+
+// FitSizeContext is FitSize, reporting the progress of every size it tries
+// to progress (which may be nil) and giving up with ctx.Err() once ctx is
+// cancelled.
+func (f *Font) FitSizeContext(ctx context.Context, progress ProgressFunc) (float64, error) {
+	if err := f.g.checkEmbedding(); err != nil {
+		return 0, err
+	}
+	return f.g.fit(newTracker(ctx, progress))
+}
+
+// generator holds a parsed font and the resolved options for rendering it.
+type generator struct {
+	fontPath  string
+	opts      Options
+	f         *opentype.Font
+	v         *variation // nil unless an instance was selected
+	cp        *codePage  // nil for Unicode char ids
+	unmapped  []rune     // Characters the code page lacks
+	sheet     []rune     // Code point of every tile, nil unless opts.Sheet is set
+	info      FaceInfo
+	meta      FontMeta
+	hinting   font.Hinting
+	dpi       float64
+	threshold int
+}
+
+func newGenerator(fontPath string, opts Options) (*generator, error) {
+	g := &generator{fontPath: fontPath, opts: opts}
+
+	// 1. Read & Parse Font (picking the requested face from collections)
+	fontBytes, err := readFontFile(fontPath)
 	if err != nil {
-		return fmt.Errorf("parsing font: %w", err)
+		return nil, err
+	}
+	if g.f, err = parseFace(fontBytes, opts.FaceIndex); err != nil {
+		return nil, err
 	}
 
 	// 2. Resolve Hinting Option
-	var h font.Hinting
-	switch hinting {
+	switch opts.Hinting {
 	case "none":
-		h = font.HintingNone
+		g.hinting = font.HintingNone
 	case "vertical":
-		h = font.HintingVertical
+		g.hinting = font.HintingVertical
 	default:
-		h = font.HintingFull // Default to crisp/sharp
+		g.hinting = font.HintingFull // Default to crisp/sharp
+		g.opts.Hinting = "full"
 	}
 
-	// 3. Setup Font Face
-	face, err := opentype.NewFace(f, &opentype.FaceOptions{
-		Size:    float64(size),
-		DPI:     72,
-		Hinting: h, // Use the selected hinting
-	})
+	// 3. Check Options & Font Metadata
+	tables, err := readTables(fontBytes, opts.FaceIndex)
 	if err != nil {
-		return fmt.Errorf("creating face: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if cerr := face.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
+	g.info = faceInfo(g.f, tables)
+	g.meta = fontMeta(g.f, tables, g.info)
 
-	// 4. Metrics & Canvas Setup
-	metrics := face.Metrics()
-	ascent := metrics.Ascent.Ceil()
-	lineHeight := metrics.Height.Ceil()
-
-	var totalWidth int
-	var validCharCount int
+	// Option errors are ErrInvalidOptions.
+	if err := validateSupersample(opts.Supersample, opts.SupersampleFilter); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if err := validateLCD(opts.LCD, opts.Supersample, opts.Antialias); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if err := validateCoverage(opts.Gamma, opts.Contrast, opts.Alpha); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if err := validateSynthetic(opts.Embolden, opts.Oblique); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if err := validateMonospace(opts.Monospace, opts.CellWidth); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if err := validateSheet(opts.Sheet, opts.SheetMap, opts.FitWidth, opts.FitHeight, opts.Charset); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if g.cp, err = newCodePage(opts.Charset); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if g.cp != nil {
+		var chars []rune
+		for _, r := range opts.Chars {
+			if _, ok := g.cp.id(r); ok {
+				chars = append(chars, r)
+			} else {
+				g.unmapped = append(g.unmapped, r)
+			}
+		}
+		g.opts.Chars = string(chars)
+		if len(chars) == 0 {
+			return nil, withKind(ErrInvalidOptions, fmt.Errorf("none of the characters are in charset %s", g.cp.name))
+		}
+	}
+	if opts.Sheet != "" {
+		g.sheet = sheetTable(opts.Sheet, opts.SheetMap)
+		g.opts.Chars = sheetChars(g.sheet)
+		g.opts.Padding, g.opts.Spacing = [4]int{}, [2]int{}
+		if opts.Monospace == "" {
+			g.opts.Monospace = MonospaceCenter
+		}
+	}
+	if opts.Workers < 0 {
+		return nil, withKind(ErrInvalidOptions, fmt.Errorf("workers cannot be negative"))
+	}
+	if err := validateSpacing(opts.Padding, opts.Spacing); err != nil {
+		return nil, withKind(ErrInvalidOptions, err)
+	}
+	if opts.FitWidth < 0 || opts.FitHeight < 0 {
+		return nil, withKind(ErrInvalidOptions, fmt.Errorf("texture budget cannot be negative"))
+	}
+	g.dpi = opts.DPI
+	if g.dpi == 0 {
+		g.dpi = defaultDPI
+	}
+	g.threshold = opts.Threshold
+	if g.threshold == 0 {
+		g.threshold = defaultThreshold
+	}
+	if g.threshold < 1 || g.threshold > 255 {
+		return nil, withKind(ErrInvalidOptions, fmt.Errorf("threshold must be between 1 and 255"))
+	}
 
-	// Measure loop
-	for _, char := range chars {
-		if _, advance, ok := face.GlyphBounds(char); ok {
-			totalWidth += advance.Ceil() + padding
-			validCharCount++
+	if opts.NamedInstance != "" || len(opts.Axes) > 0 {
+		if g.v, err = newVariation(tables, g.f, opts.NamedInstance, opts.Axes); err != nil {
+			// Errors in the variation tables make the font invalid.
+			return nil, withKind(ErrFontInvalid, fmt.Errorf("selecting instance: %w", err))
+		}
+		g.info.Style = g.v.name
+		g.info.FullName = strings.TrimSpace(g.info.Family + " " + g.v.name)
+		g.info.Bold, g.info.Italic = g.v.bold, g.v.italic
+		g.meta.Style = g.v.name
+		if g.v.weight > 0 {
+			g.meta.WeightClass = int(math.Round(g.v.weight))
 		}
 	}
 
-	img := image.NewRGBA(image.Rect(0, 0, totalWidth, lineHeight))
+	if opts.Embolden > 0 {
+		g.info.Bold = true
+	}
+	if opts.Oblique != 0 {
+		g.info.Italic = true
+	}
+	return g, nil
+}
 
-	// Initialize Drawer
-	drawer := &font.Drawer{
-		Dst:  img,
-		Src:  image.White,
-		Face: face,
-		Dot:  fixed.P(0, ascent),
+// checkEmbedding refuses fonts whose license forbids embedding, unless
+// AllowRestricted is set.
+func (g *generator) checkEmbedding() error {
+	if g.info.Embedding == EmbeddingRestricted && !g.opts.AllowRestricted {
+		return fmt.Errorf("%w (OS/2 fsType=0x%04x)", ErrEmbeddingRestricted, g.info.FsType)
 	}
+	return nil
+}
 
-	// 5. Draw Characters Individually
-	charPositions := make(map[rune]int)
-	currentX := 0
+// plainFace creates a face at the given point size (variable fonts get
+// their own face for the chosen instance).
+func (g *generator) plainFace(pointSize float64) (font.Face, error) {
+	if g.v != nil {
+		return newVariableFace(g.f, g.v, pointSize, g.dpi, g.hinting)
+	}
+	return opentype.NewFace(g.f, &opentype.FaceOptions{
+		Size:    pointSize,
+		DPI:     g.dpi,
+		Hinting: g.hinting, // Use the selected hinting
+	})
+}
 
-	for _, char := range chars {
-		_, advance, ok := face.GlyphBounds(char)
-		if !ok {
-			continue
-		}
+// newFace creates a face at sz points with any synthetic styles applied,
+// scaled from the base point size of the atlas.
+func (g *generator) newFace(sz, base float64) (font.Face, error) {
+	face, err := g.plainFace(sz)
+	if err != nil {
+		return nil, err
+	}
+	return newSyntheticFace(face, g.opts.Embolden*sz/base, g.opts.Oblique), nil
+}
 
-		width := advance.Ceil()
+// generate renders the atlas at size (in SizeMode units) and writes the files.
+func (g *generator) generate(tr *tracker, outPrefix string, size float64) (Stats, error) {
+	opts := g.opts
+	format, padding, spacing := opts.Format, opts.Padding, opts.Spacing
 
-		// Record position
-		charPositions[char] = currentX
+	// 4. Rasterise & Lay Out the Glyphs
+	lay, err := g.layout(tr, size)
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := tr.cancelled(); err != nil {
+		return Stats{}, err
+	}
+	ascent, lineHeight := lay.ascent, lay.lineHeight
 
-		// CRITICAL FIX: Explicitly set the Dot to the exact integer position.
-		// This prevents sub-pixel accumulation errors (drifting) and ensures
-		// the image pixels align 1:1 with the FNT coordinates.
-		drawer.Dot = fixed.P(currentX, ascent)
+	faceName := g.info.DisplayName()
+	if faceName == "" {
+		faceName = filepath.Base(g.fontPath)
+	}
 
-		// Draw
-		drawer.DrawString(string(char))
+	// 5. Copy the Glyph Bitmaps into the Atlas (or the Tileset Grid)
+	var img *image.RGBA
+	if opts.Sheet != "" {
+		img = sheetImage(lay, g.sheet)
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, lay.width, lay.height))
+		for _, t := range lay.tiles {
+			at := image.Pt(t.x+padding[3], t.y+padding[0])
+			draw.Draw(img, t.img.Rect.Add(at), t.img, image.Point{}, draw.Src)
+		}
+	}
 
-		// Advance local integer tracker
-		currentX += width + padding
+	// 6. Save Image (the image, FNT or sheet map, and sidecar count as written files)
+	fileCount := 1
+	if opts.Sheet == "" || opts.SheetListing {
+		fileCount++
+	}
+	if opts.EmitMeta {
+		fileCount++
+	}
+	var files []string
+	step := tr.counter(StageWrite, size, fileCount)
+	written := func(path string) {
+		files = append(files, path)
+		step()
 	}
 
-	// 6. Save Image
 	ext := "." + format
 	if err := func() error {
 		imgFile, err := os.Create(outPrefix + ext)
@@ -118,22 +448,31 @@ func Generate(fontPath string, size int, chars string, outPrefix string, format
 			}
 		}()
 
+		out := atlasImage(img, format, opts.Alpha, opts.LCD != "")
 		if format == "bmp" {
-			if err := EncodeBMP(imgFile, img); err != nil {
+			if err := EncodeBMP(imgFile, out); err != nil {
 				return err
 			}
 		} else {
-			if err := png.Encode(imgFile, img); err != nil {
+			if err := png.Encode(imgFile, out); err != nil {
 				return err
 			}
 		}
 		return nil
 	}(); err != nil {
-		return err
+		return Stats{}, err
 	}
+	written(outPrefix + ext)
 
-	// 7. Save FNT Data
-	if err := func() error {
+	// 7. Save FNT Data (sheets only get the optional tile listing)
+	if opts.Sheet != "" {
+		if opts.SheetListing {
+			if err := writeSheetListing(outPrefix+".txt", opts.Sheet, g.sheet); err != nil {
+				return Stats{}, err
+			}
+			written(outPrefix + ".txt")
+		}
+	} else if err := func() error {
 		fntFile, err := os.Create(outPrefix + ".fnt")
 		if err != nil {
 			return err
@@ -147,36 +486,76 @@ func Generate(fontPath string, size int, chars string, outPrefix string, format
 
 		fileName := filepath.Base(outPrefix) + ext
 
-		if _, err := fmt.Fprintf(fntFile, "info face=\"%s\" size=%d bold=0 italic=0 charset=\"\" unicode=0 stretchH=100 smooth=1 aa=1 padding=0,0,0,0 spacing=%d,1\n", filepath.Base(fontPath), size, padding); err != nil {
+		// LCD atlases are tagged with their subpixel order (e.g. lcd=rgb).
+		lcd := ""
+		if opts.LCD != "" {
+			lcd = " lcd=" + opts.LCD
+		}
+		// BMFont convention: the em size in pixels, negated when the size
+		// matches a pixel height instead.
+		infoSize := int(math.Round(lay.ppem))
+		if opts.SizeMode != SizePoints {
+			infoSize = -int(math.Round(size))
+		}
+		// Ids are Unicode code points (unicode=1) unless a code page is set.
+		charset := ""
+		if g.cp != nil {
+			charset = g.cp.name
+		}
+		if _, err := fmt.Fprintf(fntFile, "info face=\"%s\" size=%d bold=%d italic=%d charset=\"%s\" unicode=%d stretchH=100 smooth=%d aa=%d%s padding=%d,%d,%d,%d spacing=%d,%d\n", faceName, infoSize, boolInt(g.info.Bold), boolInt(g.info.Italic), charset, boolInt(g.cp == nil), boolInt(lay.antialias), boolInt(lay.antialias), lcd,
+			padding[0], padding[1], padding[2], padding[3], spacing[0], spacing[1]); err != nil {
 			return err
 		}
-		if _, err := fmt.Fprintf(fntFile, "common lineHeight=%d base=%d scaleW=%d scaleH=%d pages=1 packed=0\n", lineHeight, ascent, totalWidth, lineHeight); err != nil {
+		if _, err := fmt.Fprintf(fntFile, "common lineHeight=%d base=%d scaleW=%d scaleH=%d pages=1 packed=0\n", lineHeight, ascent, lay.width, lay.height); err != nil {
 			return err
 		}
 		if _, err := fmt.Fprintf(fntFile, "page id=0 file=\"%s\"\n", fileName); err != nil {
 			return err
 		}
-		if _, err := fmt.Fprintf(fntFile, "chars count=%d\n", validCharCount); err != nil {
+		if _, err := fmt.Fprintf(fntFile, "chars count=%d\n", len(lay.cells)); err != nil {
 			return err
 		}
 
-		for _, char := range chars {
-			_, advance, ok := face.GlyphBounds(char)
-			if !ok {
-				continue
+		// Rectangles include the padding; the offsets move it back out.
+		for _, c := range lay.cells {
+			t := lay.tiles[c.tile]
+			w, h := lay.rectSize(t)
+			id := int(c.char)
+			if g.cp != nil {
+				id, _ = g.cp.id(c.char)
 			}
-			width := advance.Ceil()
-			xPos := charPositions[char]
-
-			if _, err := fmt.Fprintf(fntFile, "char id=%d x=%d y=0 width=%d height=%d xoffset=0 yoffset=0 xadvance=%d page=0 chnl=15\n",
-				char, xPos, width, lineHeight, width); err != nil {
+			if _, err := fmt.Fprintf(fntFile, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=%d xadvance=%d page=0 chnl=15\n",
+				id, t.x, t.y, w, h, -lay.overhangL-padding[3], -padding[0], c.xadvance); err != nil {
 				return err
 			}
 		}
 		return nil
 	}(); err != nil {
-		return err
+		return Stats{}, err
+	} else {
+		written(outPrefix + ".fnt")
 	}
 
-	return nil
+	// 8. Save Metadata Sidecar
+	if opts.EmitMeta {
+		meta := g.meta
+		meta.Size, meta.SizeMode, meta.DPI, meta.Hinting = size, opts.SizeMode, g.dpi, opts.Hinting
+		if err := writeMeta(outPrefix+".json", meta); err != nil {
+			return Stats{}, err
+		}
+		written(outPrefix + ".json")
+	}
+
+	stats := lay.stats()
+	stats.Unmapped = string(g.unmapped)
+	stats.Files = files
+	return stats, nil
+}
+
+// boolInt formats a flag the way BMFont does (0/1).
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
 }
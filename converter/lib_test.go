@@ -1,9 +1,12 @@
 package converter
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
 )
 
 func TestGenerate(t *testing.T) {
@@ -41,3 +44,35 @@ func TestGenerate(t *testing.T) {
 		t.Errorf("Expected %s.fnt to exist", outPrefix)
 	}
 }
+
+func TestOpenFont(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	opts := Options{Chars: "ABCabc", Format: "png", Spacing: [2]int{2, 2}, Hinting: "full"}
+
+	f, err := OpenFont(path, opts)
+	if err != nil {
+		t.Fatalf("OpenFont() failed: %v", err)
+	}
+	if info := f.Info(); info.Family != "Go" {
+		t.Errorf("Info().Family = %q, want Go", info.Family)
+	}
+
+	// One parsed font gives the same files as parsing it again for each size.
+	for _, size := range []float64{12, 32} {
+		shared, single := filepath.Join(t.TempDir(), "font"), filepath.Join(t.TempDir(), "font")
+		if _, err := f.Generate(shared, size); err != nil {
+			t.Fatalf("Generate(%v) failed: %v", size, err)
+		}
+		opts.Size = size
+		if err := GenerateWithOptions(path, single, opts); err != nil {
+			t.Fatalf("GenerateWithOptions(%v) failed: %v", size, err)
+		}
+		for _, ext := range []string{".png", ".fnt"} {
+			a, _ := os.ReadFile(shared + ext)
+			b, _ := os.ReadFile(single + ext)
+			if len(a) == 0 || !bytes.Equal(a, b) {
+				t.Errorf("size %v: %s differs from a single conversion", size, ext)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the name of the manifest in the output directory.
+const ManifestFile = "ttf2bmp-manifest.json"
+
+// Manifest records what every job of a batch was built from and what it
+// wrote, so that a later run can skip the jobs that haven't changed. Jobs
+// are keyed by the caller (e.g. font, face and size).
+type Manifest struct {
+	Jobs map[string]ManifestEntry `json:"jobs"`
+}
+
+// ManifestEntry is one job of a Manifest.
+type ManifestEntry struct {
+	FontHash    string            `json:"fontHash"`    // SHA-256 of the font file
+	OptionsHash string            `json:"optionsHash"` // See HashOptions
+	Size        float64           `json:"size"`        // Size rendered (the fitted one with a budget)
+	Outputs     map[string]string `json:"outputs"`     // SHA-256 by file, relative to the manifest
+}
+
+// ReadManifest reads the manifest in dir; without one, it is empty.
+func ReadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Jobs: map[string]ManifestEntry{}}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if m.Jobs == nil {
+		m.Jobs = map[string]ManifestEntry{}
+	}
+	return m, nil
+}
+
+// Write saves the manifest in dir, replacing the previous one only once the
+// new one is complete.
+func (m *Manifest) Write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// UpToDate returns the entry of job key if it was built from the same font
+// and options, and all its outputs in dir are still as written.
+func (m *Manifest) UpToDate(dir, key, fontHash, optionsHash string) (ManifestEntry, bool) {
+	e, ok := m.Jobs[key]
+	if !ok || e.FontHash != fontHash || e.OptionsHash != optionsHash || len(e.Outputs) == 0 {
+		return ManifestEntry{}, false
+	}
+	for name, want := range e.Outputs {
+		if got, err := HashFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil || got != want {
+			return ManifestEntry{}, false
+		}
+	}
+	return e, true
+}
+
+// Record stores job key, hashing the files it wrote (paths within dir).
+func (m *Manifest) Record(dir, key, fontHash, optionsHash string, size float64, files []string) error {
+	e := ManifestEntry{FontHash: fontHash, OptionsHash: optionsHash, Size: size, Outputs: map[string]string{}}
+	for _, path := range files {
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if e.Outputs[filepath.ToSlash(name)], err = HashFile(path); err != nil {
+			return err
+		}
+	}
+	m.Jobs[key] = e
+	return nil
+}
+
+// Forget removes job key, so that it is rebuilt next time.
+func (m *Manifest) Forget(key string) {
+	delete(m.Jobs, key)
+}
+
+// HashFile returns the SHA-256 of a file, in hex.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashOptions returns the SHA-256 of everything in opts that affects the
+// output (Workers doesn't), plus any extra settings of the caller (e.g. how
+// output files are named).
+func HashOptions(opts Options, extra ...string) string {
+	opts.Workers = 0
+	data, _ := json.Marshal(struct {
+		Options Options
+		Extra   []string
+	}{opts, extra})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
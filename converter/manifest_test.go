@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestManifest(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	dir := t.TempDir()
+	opts := Options{Size: 16, Chars: "ABC", Format: "png", EmitMeta: true}
+
+	stats, err := GenerateWithStats(path, filepath.Join(dir, "font"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "font.png"), filepath.Join(dir, "font.fnt"), filepath.Join(dir, "font.json")}
+	if !reflect.DeepEqual(stats.Files, want) {
+		t.Errorf("Files = %q, want %q", stats.Files, want)
+	}
+
+	fontHash, err := HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	optionsHash := HashOptions(opts, "{name}-{size}")
+
+	// Workers don't change the output; everything else does.
+	if HashOptions(Options{Size: 16, Chars: "ABC", Format: "png", EmitMeta: true, Workers: 4}, "{name}-{size}") != optionsHash {
+		t.Error("Workers changed the options hash")
+	}
+	for _, other := range []string{HashOptions(opts), HashOptions(Options{Size: 17, Chars: "ABC", Format: "png", EmitMeta: true}, "{name}-{size}")} {
+		if other == optionsHash {
+			t.Error("different options share a hash")
+		}
+	}
+
+	// A missing manifest is empty; a written one reads back the same.
+	m, err := ReadManifest(dir)
+	if err != nil || len(m.Jobs) != 0 {
+		t.Fatalf("ReadManifest() = %+v, %v; want an empty manifest", m, err)
+	}
+	if err := m.Record(dir, "font@16", fontHash, optionsHash, 16, stats.Files); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = ReadManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := m.UpToDate(dir, "font@16", fontHash, optionsHash)
+	if !ok || e.Size != 16 || len(e.Outputs) != 3 {
+		t.Errorf("UpToDate() = %+v, %v; want the recorded entry", e, ok)
+	}
+
+	// Other keys, fonts or options are not up to date.
+	if _, ok := m.UpToDate(dir, "font@12", fontHash, optionsHash); ok {
+		t.Error("unknown job is up to date")
+	}
+	if _, ok := m.UpToDate(dir, "font@16", "other", optionsHash); ok {
+		t.Error("job with another font is up to date")
+	}
+	if _, ok := m.UpToDate(dir, "font@16", fontHash, HashOptions(opts)); ok {
+		t.Error("job with other options is up to date")
+	}
+
+	// Nor are jobs whose outputs were changed or removed.
+	if err := os.WriteFile(filepath.Join(dir, "font.fnt"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.UpToDate(dir, "font@16", fontHash, optionsHash); ok {
+		t.Error("job with an edited output is up to date")
+	}
+	if _, err := GenerateWithStats(path, filepath.Join(dir, "font"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.UpToDate(dir, "font@16", fontHash, optionsHash); !ok {
+		t.Error("rebuilt job is not up to date")
+	}
+	if err := os.Remove(filepath.Join(dir, "font.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.UpToDate(dir, "font@16", fontHash, optionsHash); ok {
+		t.Error("job with a missing output is up to date")
+	}
+
+	m.Forget("font@16")
+	if len(m.Jobs) != 0 {
+		t.Errorf("Forget() left %d jobs", len(m.Jobs))
+	}
+
+	// A corrupt manifest is an error, not an empty one.
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(dir); err == nil {
+		t.Error("expected an error for a corrupt manifest")
+	}
+}
@@ -0,0 +1,90 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// FontMeta is the font description written as a JSON sidecar next to the
+// .fnt when Options.EmitMeta is set. Metrics are in font units (unscaled).
+type FontMeta struct {
+	Family      string `json:"family"`
+	Style       string `json:"style"`
+	WeightClass int    `json:"weightClass"` // OS/2 usWeightClass, or the wght axis value
+	Version     string `json:"version"`
+	Copyright   string `json:"copyright"`
+	LicenseURL  string `json:"licenseURL"`
+	UnitsPerEm  int    `json:"unitsPerEm"`
+	Ascender    int    `json:"ascender"`  // hhea ascender
+	Descender   int    `json:"descender"` // hhea descender (negative below the baseline)
+	LineGap     int    `json:"lineGap"`   // hhea lineGap
+
+	// Rendering settings, filled in by Generate.
+	Size     float64 `json:"size,omitempty"`     // As requested (see SizeMode)
+	SizeMode string  `json:"sizeMode,omitempty"` // "line" or "cap" for pixel sizes
+	DPI      float64 `json:"dpi,omitempty"`
+	Hinting  string  `json:"hinting,omitempty"`
+}
+
+// ReadFontMeta reads the metadata of face 'index' of the font at path.
+func ReadFontMeta(path string, index int) (FontMeta, error) {
+	data, err := readFontFile(path)
+	if err != nil {
+		return FontMeta{}, err
+	}
+	f, err := parseFace(data, index)
+	if err != nil {
+		return FontMeta{}, err
+	}
+	tables, err := readTables(data, index)
+	if err != nil {
+		return FontMeta{}, err
+	}
+	return fontMeta(f, tables, faceInfo(f, tables)), nil
+}
+
+// fontMeta collects the FontMeta of a parsed face; names come from info so
+// that a selected variable instance is reflected.
+func fontMeta(f *opentype.Font, tables sfntTables, info FaceInfo) FontMeta {
+	var buf sfnt.Buffer
+	name := func(id sfnt.NameID) string {
+		s, _ := f.Name(&buf, id)
+		return strings.TrimSpace(s)
+	}
+
+	m := FontMeta{
+		Family:     info.Family,
+		Style:      info.Style,
+		Version:    name(sfnt.NameIDVersion),
+		Copyright:  name(sfnt.NameIDCopyright),
+		LicenseURL: name(sfnt.NameIDLicenseURL),
+		UnitsPerEm: int(f.UnitsPerEm()),
+	}
+	if os2 := tables[tagOf("OS/2")]; len(os2) >= 6 {
+		m.WeightClass = int(binary.BigEndian.Uint16(os2[4:]))
+	}
+	if hhea := tables[tagOf("hhea")]; len(hhea) >= 10 {
+		m.Ascender = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+		m.Descender = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+		m.LineGap = int(int16(binary.BigEndian.Uint16(hhea[8:])))
+	}
+	return m
+}
+
+// writeMeta saves m as indented JSON.
+func writeMeta(path string, m FontMeta) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep "&" readable in copyright strings
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
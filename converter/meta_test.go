@@ -0,0 +1,43 @@
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+func TestEmitMeta(t *testing.T) {
+	path := writeTempFont(t, "Go-Bold.ttf", gobold.TTF)
+	outPrefix := filepath.Join(t.TempDir(), "bold")
+	opts := Options{Size: 24, Chars: "A", Format: "png", Spacing: [2]int{2, 2}, EmitMeta: true}
+	if err := GenerateWithOptions(path, outPrefix, opts); err != nil {
+		t.Fatalf("GenerateWithOptions() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outPrefix + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got FontMeta
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid sidecar: %v", err)
+	}
+
+	want, err := ReadFontMeta(path, 0)
+	if err != nil {
+		t.Fatalf("ReadFontMeta() failed: %v", err)
+	}
+	want.Size, want.DPI, want.Hinting = 24, 72, "full"
+	if got != want {
+		t.Errorf("sidecar = %+v, want %+v", got, want)
+	}
+	if got.Family != "Go" || got.Style != "Bold" || got.WeightClass != 600 || got.UnitsPerEm != 2048 || got.Version == "" {
+		t.Errorf("unexpected metadata: %+v", got)
+	}
+	if got.Ascender <= 0 || got.Descender >= 0 {
+		t.Errorf("ascender=%d descender=%d, want positive/negative", got.Ascender, got.Descender)
+	}
+}
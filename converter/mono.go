@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Monochrome rendering binarises the antialiased masks so that glyphs come
+// out as crisp 1-bit pixels (aa=0 smooth=0 in the FNT).
+
+const defaultThreshold = 128
+
+// maxPixelGridPPEM is the finest design grid still treated as a pixel font.
+const maxPixelGridPPEM = 64
+
+// resolveAntialias decides whether to render with antialiasing for the
+// "on", "off" or "auto" setting ("" means "on"). "auto" turns it off when
+// the pixels per em (ppem) are an exact multiple of the font's pixel grid.
+func resolveAntialias(mode string, f *opentype.Font, chars string, ppem float64) (bool, error) {
+	switch mode {
+	case "", "on":
+		return true, nil
+	case "off":
+		return false, nil
+	case "auto":
+		grid := pixelGrid(f, chars)
+		if grid == 0 {
+			return true, nil
+		}
+		// Output pixels per design pixel must be a whole number.
+		scale := ppem * float64(grid) / float64(f.UnitsPerEm())
+		return scale < 1 || math.Abs(scale-math.Round(scale)) > 1e-6, nil
+	}
+	return false, fmt.Errorf("unknown antialiasing mode %q (use 'on', 'off' or 'auto')", mode)
+}
+
+// binarize makes every pixel of img either transparent or opaque white,
+// depending on whether its coverage reaches threshold.
+func binarize(img *image.RGBA, threshold uint8) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		var v uint8
+		if img.Pix[i+3] >= threshold {
+			v = 255
+		}
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, v
+	}
+}
+
+// pixelGrid returns the size of one design pixel in font units if every
+// outline point and advance of chars lies on a common grid (as in fonts
+// drawn on a pixel grid), or 0 if the font does not look like a pixel font.
+func pixelGrid(f *opentype.Font, chars string) int {
+	upem := int(f.UnitsPerEm())
+	ppem := fixed.I(upem) // Scale 1: one pixel per font unit
+	var buf sfnt.Buffer
+	grid, points := 0, 0
+	add := func(v fixed.Int26_6) bool {
+		if v&63 != 0 {
+			return false // Off the font-unit grid (scaled or hinted outline)
+		}
+		grid = gcd(grid, abs(int(v>>6)))
+		return true
+	}
+
+	for _, r := range chars {
+		g, err := f.GlyphIndex(&buf, r)
+		if err != nil || g == 0 {
+			continue
+		}
+		adv, err := f.GlyphAdvance(&buf, g, ppem, font.HintingNone)
+		if err != nil || !add(adv) {
+			return 0
+		}
+		segs, err := f.LoadGlyph(&buf, g, ppem, nil)
+		if err != nil {
+			return 0
+		}
+		for _, seg := range segs {
+			n := 1
+			switch seg.Op {
+			case sfnt.SegmentOpQuadTo:
+				n = 2
+			case sfnt.SegmentOpCubeTo:
+				n = 3
+			}
+			for _, p := range seg.Args[:n] {
+				if !add(p.X) || !add(p.Y) {
+					return 0
+				}
+				points++
+			}
+		}
+	}
+
+	if points == 0 || grid == 0 || upem/grid > maxPixelGridPPEM {
+		return 0
+	}
+	return grid
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
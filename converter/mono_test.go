@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// buildPixelFont turns Go Regular (2048 units per em) into a pixel font on a
+// 256-unit grid (8 pixels per em): glyph g becomes a 1x6 pixel bar, every
+// other glyph is empty and every advance is 2 pixels.
+func buildPixelFont(t *testing.T, g int) []byte {
+	t.Helper()
+	flavor, tables := splitSFNT(t, goregular.TTF)
+	byTag := sfntTables{}
+	for _, tbl := range tables {
+		byTag[tbl.tag] = tbl.data
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(byTag[tagMaxp][4:]))
+
+	pts := []glyfPoint{{0, 0, true}, {0, 1536, true}, {256, 1536, true}, {256, 0, true}}
+	glyph := encodeSimpleGlyph(pointsBBox(pts), []int{3}, nil, pts, false)
+	loca := make([]byte, 4*(numGlyphs+1))
+	for i := g + 1; i <= numGlyphs; i++ {
+		binary.BigEndian.PutUint32(loca[4*i:], uint32(len(glyph)))
+	}
+
+	for i, tbl := range tables {
+		switch tbl.tag {
+		case tagGlyf:
+			tables[i].data = glyph
+		case tagLoca:
+			tables[i].data = loca
+		case tagHead:
+			tables[i].data = append([]byte(nil), tbl.data...)
+			binary.BigEndian.PutUint16(tables[i].data[50:], 1) // Long loca
+		case tagHmtx:
+			tables[i].data = append([]byte(nil), tbl.data...)
+			for j := 0; j+4 <= len(tbl.data); j += 4 {
+				binary.BigEndian.PutUint32(tables[i].data[j:], 512<<16) // Advance 512, lsb 0
+			}
+		}
+	}
+	return assembleSFNT(flavor, tables)
+}
+
+func TestMonochrome(t *testing.T) {
+	base, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid := pixelGrid(base, "Ag"); grid != 0 {
+		t.Errorf("pixelGrid(Go Regular) = %d, want 0", grid)
+	}
+
+	idx, _ := base.GlyphIndex(nil, 'l')
+	pixel := buildPixelFont(t, int(idx))
+	f, err := opentype.Parse(pixel)
+	if err != nil {
+		t.Fatalf("parsing pixel font: %v", err)
+	}
+	if grid := pixelGrid(f, "l "); grid != 256 {
+		t.Errorf("pixelGrid(pixel font) = %d, want 256", grid)
+	}
+	path := writeTempFont(t, "pixel.ttf", pixel)
+
+	for _, tc := range []struct {
+		aa   string
+		size float64
+		want bool // antialiased
+	}{
+		{"on", 16, true},
+		{"off", 12, false},
+		{"auto", 16, false}, // 2 pixels per design pixel
+		{"auto", 12, true},  // 1.5 pixels per design pixel
+	} {
+		prefix := filepath.Join(t.TempDir(), "pixel")
+		opts := Options{Size: tc.size, Chars: "l", Format: "png", Hinting: "none", Antialias: tc.aa}
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatalf("aa=%s size=%g: %v", tc.aa, tc.size, err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		want := "smooth=0 aa=0"
+		if tc.want {
+			want = "smooth=1 aa=1"
+		}
+		if !strings.Contains(string(fnt), want) {
+			t.Errorf("aa=%s size=%g: info line lacks %q", tc.aa, tc.size, want)
+		}
+
+		if !tc.want {
+			img := readPNG(t, prefix+".png")
+			b := img.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if _, _, _, a := img.At(x, y).RGBA(); a != 0 && a != 0xffff {
+						t.Fatalf("aa=%s size=%g: pixel (%d,%d) has alpha %d", tc.aa, tc.size, x, y, a>>8)
+					}
+				}
+			}
+		}
+	}
+
+	opts := Options{Size: 16, Chars: "l", Format: "png", Antialias: "sometimes"}
+	if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "bad"), opts); err == nil {
+		t.Error("expected an error for an unknown antialiasing mode")
+	}
+}
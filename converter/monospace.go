@@ -0,0 +1,49 @@
+package converter
+
+import "fmt"
+
+// Monospace alignments: how glyphs sit in fixed-width cells.
+const (
+	MonospaceLeft   = "left"   // The pen at the cell's left edge, as in the font
+	MonospaceCenter = "center" // The ink centred in the cell
+)
+
+// validateMonospace checks the cell alignment and width.
+func validateMonospace(align string, cellWidth int) error {
+	switch align {
+	case "":
+		if cellWidth != 0 {
+			return fmt.Errorf("cell width requires a monospace alignment")
+		}
+		return nil
+	case MonospaceLeft, MonospaceCenter:
+	default:
+		return fmt.Errorf("unknown monospace alignment %q (use 'left' or 'center')", align)
+	}
+	if cellWidth < 0 || cellWidth > 4096 {
+		return fmt.Errorf("cell width must be between 1 and 4096 pixels")
+	}
+	return nil
+}
+
+// glyphMetrics is a glyph's advance and horizontal ink extent in pixels,
+// relative to the pen (both 0 for blank glyphs).
+type glyphMetrics struct {
+	char           rune
+	advance        int
+	inkMin, inkMax int
+}
+
+// placeInCell returns the pen position within a cell of the given width and
+// whether the ink overflows the cell (beyond the oblique overhang).
+func placeInCell(m glyphMetrics, width int, align string, overhangL, overhangR int) (penX int, overflow bool) {
+	if align == MonospaceCenter {
+		if m.inkMax > m.inkMin {
+			penX = (width-(m.inkMax-m.inkMin))/2 - m.inkMin
+		} else {
+			penX = (width - m.advance) / 2
+		}
+	}
+	overflow = m.inkMax > m.inkMin && (penX+m.inkMin < -overhangL || penX+m.inkMax > width+overhangR)
+	return penX, overflow
+}
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestMonospace(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	type cell struct{ x, width, xadvance, inkL, inkR int }
+	render := func(opts Options) (map[rune]cell, Stats) {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "font")
+		opts.Size, opts.Chars, opts.Format = 24, "iWl.", "png"
+		stats, err := GenerateWithStats(path, prefix, opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		img := readPNG(t, prefix+".png")
+		cells := make(map[rune]cell)
+		for _, line := range strings.Split(string(fnt), "\n") {
+			var id, y, height, xoffset int
+			var c cell
+			if _, err := fmt.Sscanf(line, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=0 xadvance=%d",
+				&id, &c.x, &y, &c.width, &height, &xoffset, &c.xadvance); err != nil {
+				continue
+			}
+			// Ink columns, relative to the cell.
+			c.inkL, c.inkR = -1, -1
+			for px := 0; px < c.width; px++ {
+				for py := 0; py < height; py++ {
+					if _, _, _, a := img.At(c.x+px, py).RGBA(); a != 0 {
+						if c.inkL < 0 {
+							c.inkL = px
+						}
+						c.inkR = px
+						break
+					}
+				}
+			}
+			cells[rune(id)] = c
+		}
+		return cells, stats
+	}
+
+	prop, _ := render(Options{})
+	for _, align := range []string{MonospaceLeft, MonospaceCenter} {
+		cells, stats := render(Options{Monospace: align})
+		for char, c := range cells {
+			if c.xadvance != prop['W'].xadvance || c.width != c.xadvance {
+				t.Errorf("%s %q: width %d xadvance %d, want the widest advance %d", align, char, c.width, c.xadvance, prop['W'].xadvance)
+			}
+		}
+		if stats.Overflow != "" {
+			t.Errorf("%s: %q overflow the widest advance", align, stats.Overflow)
+		}
+		if align == MonospaceLeft && cells['i'].inkL != prop['i'].inkL {
+			t.Errorf("left: 'i' ink starts at %d, want %d as in the font", cells['i'].inkL, prop['i'].inkL)
+		}
+		if align == MonospaceCenter {
+			for _, char := range "il." {
+				c := cells[char]
+				if left, right := c.inkL, c.width-1-c.inkR; left-right > 1 || right-left > 1 {
+					t.Errorf("center %q: margins %d and %d", char, left, right)
+				}
+			}
+		}
+	}
+
+	// A narrower cell clips (and reports) the wide glyphs.
+	cells, stats := render(Options{Monospace: MonospaceCenter, CellWidth: 8})
+	if stats.Overflow != "W" {
+		t.Errorf("overflow %q, want \"W\"", stats.Overflow)
+	}
+	if cells['W'].xadvance != 8 || cells['W'].width != 8 {
+		t.Errorf("W cell %+v, want 8 wide", cells['W'])
+	}
+
+	for _, opts := range []Options{{Monospace: "right"}, {CellWidth: 8}} {
+		opts.Size, opts.Chars, opts.Format = 24, "i", "png"
+		if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "bad"), opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
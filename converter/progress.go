@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"context"
+	"sync"
+)
+
+// Stage is a step of a conversion, as reported to a ProgressFunc.
+type Stage string
+
+const (
+	StageRasterise Stage = "rasterise" // Glyphs rasterised
+	StagePack      Stage = "pack"      // Distinct bitmaps placed in the atlas
+	StageWrite     Stage = "write"     // Output files written
+)
+
+// Progress tells how far a conversion is: Done of Total units of Stage, at
+// Size (in SizeMode units). While a size is being fitted, every size tried
+// reports its own rasterise and pack stages.
+type Progress struct {
+	Stage Stage
+	Size  float64
+	Done  int
+	Total int
+}
+
+// ProgressFunc receives the progress of a conversion. Calls may come from
+// several goroutines but never overlap; it should return quickly, as the
+// workers wait for it.
+type ProgressFunc func(Progress)
+
+// tracker carries the context and progress callback of one conversion
+// through the pipeline (the generator itself is shared between them).
+type tracker struct {
+	ctx      context.Context
+	progress ProgressFunc
+	mu       sync.Mutex
+}
+
+func newTracker(ctx context.Context, progress ProgressFunc) *tracker {
+	return &tracker{ctx: ctx, progress: progress}
+}
+
+// report passes a progress update to the callback, if there is one.
+func (t *tracker) report(stage Stage, size float64, done, total int) {
+	if t.progress == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress(Progress{Stage: stage, Size: size, Done: done, Total: total})
+}
+
+// counter returns a function that reports one more of total units of
+// stage done, counting them in the order the reports are made.
+func (t *tracker) counter(stage Stage, size float64, total int) func() {
+	done := 0
+	return func() {
+		if t.progress == nil {
+			return
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		done++
+		t.progress(Progress{Stage: stage, Size: size, Done: done, Total: total})
+	}
+}
+
+// cancelled returns the context's error once it is cancelled.
+func (t *tracker) cancelled() error {
+	return t.ctx.Err()
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestProgress(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	chars := largeCharset()
+	opts := Options{Size: 16, Chars: chars, Format: "png", EmitMeta: true, Workers: 4}
+
+	// Every stage counts up to its total, one at a time.
+	last := map[Stage]Progress{}
+	prefix := filepath.Join(t.TempDir(), "font")
+	stats, err := GenerateContext(context.Background(), path, prefix, opts, func(p Progress) {
+		if prev := last[p.Stage]; p.Done > p.Total || (prev.Stage != "" && p.Done <= prev.Done) {
+			t.Errorf("%s: %d/%d after %d", p.Stage, p.Done, p.Total, prev.Done)
+		}
+		if p.Size != 16 {
+			t.Errorf("%s: size %g, want 16", p.Stage, p.Size)
+		}
+		last[p.Stage] = p
+	})
+	if err != nil {
+		t.Fatalf("GenerateContext() failed: %v", err)
+	}
+	for stage, want := range map[Stage]int{StageRasterise: stats.Glyphs, StagePack: stats.Bitmaps, StageWrite: 3} {
+		if p := last[stage]; p.Done != want || p.Total != want {
+			t.Errorf("%s ended at %d/%d, want %d", stage, p.Done, p.Total, want)
+		}
+	}
+
+	// A cancelled conversion stops before writing anything.
+	ctx, cancel := context.WithCancel(context.Background())
+	prefix = filepath.Join(t.TempDir(), "cancelled")
+	_, err = GenerateContext(ctx, path, prefix, opts, func(p Progress) {
+		if p.Stage == StageRasterise && p.Done == 100 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled conversion: err = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(prefix + ".png"); !os.IsNotExist(err) {
+		t.Error("cancelled conversion wrote an image")
+	}
+
+	// Fitting stops too.
+	opts.Size, opts.FitWidth, opts.FitHeight = 0, 256, 256
+	if _, err := FitSize(path, opts); err != nil {
+		t.Fatalf("FitSize() failed: %v", err)
+	}
+	f, err := OpenFont(path, opts)
+	if err != nil {
+		t.Fatalf("OpenFont() failed: %v", err)
+	}
+	if _, err := f.FitSizeContext(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled fit: err = %v, want context.Canceled", err)
+	}
+}
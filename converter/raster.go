@@ -0,0 +1,135 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/image/font"
+)
+
+// Glyphs are rasterised concurrently. Faces aren't safe for concurrent use,
+// so every worker but the first creates its own; the bitmaps are then
+// deduplicated and packed in character order, as on the serial path.
+
+// minGlyphsPerWorker keeps small character sets on a single goroutine,
+// where creating extra faces would cost more than it saves.
+const minGlyphsPerWorker = 32
+
+// placedGlyph is a glyph ready to be rasterised: its bitmap width and the
+// pen position within it.
+type placedGlyph struct {
+	char     rune
+	xadvance int
+	width    int
+	dotX     int
+}
+
+// newHiFace creates the face supersampling (larger) and LCD rendering
+// (stretched) draw from, or returns nil if neither is enabled.
+func (g *generator) newHiFace(pointSize float64) (font.Face, error) {
+	if g.opts.LCD != "" {
+		return g.newLCDFace(pointSize)
+	}
+	if g.opts.Supersample <= 1 {
+		return nil, nil
+	}
+	return g.newFace(pointSize*float64(g.opts.Supersample), pointSize)
+}
+
+// rasterizeAll renders the glyphs, with the coverage curve and threshold
+// applied, on up to Options.Workers goroutines (0: GOMAXPROCS). face and
+// hiFace are used by the first worker. Progress is reported per glyph, and
+// the workers stop at the next glyph once the context is cancelled.
+func (g *generator) rasterizeAll(tr *tracker, size float64, lay *atlasLayout, face, hiFace font.Face, glyphs []placedGlyph) ([]*image.RGBA, error) {
+	imgs := make([]*image.RGBA, len(glyphs))
+	curve := coverageCurve(g.opts.Gamma, g.opts.Contrast)
+	step := tr.counter(StageRasterise, size, len(glyphs))
+	render := func(face, hiFace font.Face, i int) {
+		p := glyphs[i]
+		img := g.rasterize(lay, face, hiFace, p.char, p.width, p.dotX)
+		if curve != nil {
+			remapCoverage(img, curve)
+		}
+		if !lay.antialias {
+			binarize(img, uint8(g.threshold))
+		}
+		imgs[i] = img
+		step()
+	}
+
+	workers := g.opts.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = max(1, min(workers, len(glyphs)/minGlyphsPerWorker))
+	if workers == 1 {
+		for i := range glyphs {
+			if err := tr.cancelled(); err != nil {
+				return nil, err
+			}
+			render(face, hiFace, i)
+		}
+		return imgs, nil
+	}
+
+	var next atomic.Int64
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			face, hiFace := face, hiFace
+			if w > 0 {
+				var err error
+				if face, hiFace, err = g.workerFaces(lay.pointSize); err != nil {
+					errs[w] = fmt.Errorf("creating face: %w", err)
+					return
+				}
+				defer func() {
+					errs[w] = closeFaces(face, hiFace)
+				}()
+			}
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(glyphs) || tr.cancelled() != nil {
+					return
+				}
+				render(face, hiFace, i)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := tr.cancelled(); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return imgs, nil
+}
+
+// workerFaces creates a worker's own faces at the atlas point size.
+func (g *generator) workerFaces(pointSize float64) (face, hiFace font.Face, err error) {
+	if face, err = g.newFace(pointSize, pointSize); err != nil {
+		return nil, nil, err
+	}
+	if hiFace, err = g.newHiFace(pointSize); err != nil {
+		face.Close()
+		return nil, nil, err
+	}
+	return face, hiFace, nil
+}
+
+// closeFaces closes a worker's faces (hiFace may be nil).
+func closeFaces(face, hiFace font.Face) error {
+	err := face.Close()
+	if hiFace != nil {
+		err = errors.Join(err, hiFace.Close())
+	}
+	return err
+}
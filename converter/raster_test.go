@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// largeCharset is Latin, Greek and Cyrillic: enough glyphs for several workers.
+func largeCharset() string {
+	var chars []rune
+	for r := rune(0x20); r < 0x500; r++ {
+		chars = append(chars, r)
+	}
+	return string(chars)
+}
+
+func TestParallelRasterisation(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	chars := largeCharset()
+
+	for _, opts := range []Options{
+		{Size: 16},
+		{Size: 12, Supersample: 4, SupersampleFilter: "lanczos"},
+		{Size: 12, LCD: "rgb", Gamma: 1.4},
+		{Size: 20, Monospace: MonospaceCenter, Oblique: 12, Embolden: 0.5, Antialias: "off"},
+	} {
+		opts.Chars, opts.Format = chars, "png"
+		render := func(workers int) (fnt, png []byte) {
+			t.Helper()
+			opts.Workers = workers
+			// The same base name in separate directories, so the FNT page names match.
+			prefix := filepath.Join(t.TempDir(), "font")
+			if err := GenerateWithOptions(path, prefix, opts); err != nil {
+				t.Fatalf("%+v: %v", opts, err)
+			}
+			fnt, _ = os.ReadFile(prefix + ".fnt")
+			png, _ = os.ReadFile(prefix + ".png")
+			return fnt, png
+		}
+
+		serialFnt, serialPNG := render(1)
+		parallelFnt, parallelPNG := render(8)
+		if !bytes.Equal(serialFnt, parallelFnt) || !bytes.Equal(serialPNG, parallelPNG) {
+			t.Errorf("size %g supersample %d lcd %q: parallel output differs from serial", opts.Size, opts.Supersample, opts.LCD)
+		}
+	}
+}
+
+func BenchmarkRasterisation(b *testing.B) {
+	dir := b.TempDir()
+	path := filepath.Join(dir, "Go-Regular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0644); err != nil {
+		b.Fatal(err)
+	}
+	chars := largeCharset()
+
+	for _, workers := range []int{1, 0} {
+		name := "serial"
+		if workers == 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			opts := Options{Size: 32, Chars: chars, Format: "png", Supersample: 4, Workers: workers}
+			for i := 0; i < b.N; i++ {
+				if err := GenerateWithOptions(path, filepath.Join(dir, fmt.Sprintf("bench-%s", name)), opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
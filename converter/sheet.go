@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tileset sheets: a 16x16 grid of equal cells in code page order, the way
+// roguelike engines load fonts. A mapping can override the code point of
+// any tile. Sheets have no FNT; an optional text listing lists the code
+// point of each tile, in the format mappings are read in.
+
+// Sheet code pages.
+const (
+	SheetCP437 = "cp437" // IBM PC code page 437, with its graphic symbols for 0x01-0x1F and 0x7F
+)
+
+// cp437 maps each CP437 byte to its Unicode equivalent (0x00 stays blank).
+// U+0000 marks a blank tile in every table.
+var cp437 = []rune("" +
+	"\x00☺☻♥♦♣♠•◘○◙♂♀♪♫☼" +
+	"►◄↕‼¶§▬↨↑↓→←∟↔▲▼" +
+	" !\"#$%&'()*+,-./" +
+	"0123456789:;<=>?" +
+	"@ABCDEFGHIJKLMNO" +
+	"PQRSTUVWXYZ[\\]^_" +
+	"`abcdefghijklmno" +
+	"pqrstuvwxyz{|}~⌂" +
+	"ÇüéâäàåçêëèïîìÄÅ" +
+	"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ" +
+	"áíóúñÑªº¿⌐¬½¼¡«»" +
+	"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+	"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+	"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+	"αßΓπΣσµτΦΘΩδ∞φε∩" +
+	"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■ ")
+
+const sheetColumns = 16
+
+// sheetRunes returns the code page of a sheet, or nil if it is unknown.
+func sheetRunes(sheet string) []rune {
+	if sheet == SheetCP437 {
+		return cp437
+	}
+	return nil
+}
+
+// sheetTable returns the code point of every tile: the code page of sheet
+// with the entries of mapping replaced.
+func sheetTable(sheet string, mapping map[int]rune) []rune {
+	runes := append([]rune(nil), sheetRunes(sheet)...)
+	for index, r := range mapping {
+		runes[index] = r
+	}
+	return runes
+}
+
+// sheetChars returns the characters to rasterise for the tiles: every code
+// point once, without U+0000.
+func sheetChars(runes []rune) string {
+	seen := make(map[rune]bool, len(runes))
+	var chars []rune
+	for _, r := range runes {
+		if r != 0 && !seen[r] {
+			seen[r] = true
+			chars = append(chars, r)
+		}
+	}
+	return string(chars)
+}
+
+// validateSheet checks the sheet code page, its mapping and the options
+// they can't be combined with.
+func validateSheet(sheet string, mapping map[int]rune, fitWidth, fitHeight int, charset string) error {
+	if sheet == "" {
+		if len(mapping) > 0 {
+			return fmt.Errorf("a sheet mapping requires a sheet")
+		}
+		return nil
+	}
+	runes := sheetRunes(sheet)
+	if runes == nil {
+		return fmt.Errorf("unknown sheet code page %q (use 'cp437')", sheet)
+	}
+	for index, r := range mapping {
+		if index < 0 || index >= len(runes) {
+			return fmt.Errorf("sheet mapping: tile 0x%02X is out of range (0x00-0x%02X)", index, len(runes)-1)
+		}
+		if r != 0 && !utf8.ValidRune(r) {
+			return fmt.Errorf("sheet mapping: tile 0x%02X: U+%04X is not a valid code point", index, r)
+		}
+	}
+	if fitWidth > 0 || fitHeight > 0 {
+		return fmt.Errorf("tileset sheets cannot be fitted to a texture budget")
+	}
+	if charset != "" {
+		return fmt.Errorf("tileset sheets have no char ids to convert to a charset")
+	}
+	return nil
+}
+
+// sheetImage draws the glyphs into a 16-column grid of cells, one per code
+// page entry. Entries the font has no glyph for, and U+0000, stay blank.
+func sheetImage(lay *atlasLayout, runes []rune) *image.RGBA {
+	w := lay.overhangL + lay.cellWidth + lay.overhangR
+	h := lay.lineHeight
+	rows := (len(runes) + sheetColumns - 1) / sheetColumns
+	img := image.NewRGBA(image.Rect(0, 0, sheetColumns*w, rows*h))
+
+	tiles := make(map[rune]*image.RGBA, len(lay.cells))
+	for _, c := range lay.cells {
+		tiles[c.char] = lay.tiles[c.tile].img
+	}
+	for i, r := range runes {
+		tile, ok := tiles[r]
+		if !ok {
+			continue
+		}
+		at := image.Pt(i%sheetColumns*w, i/sheetColumns*h)
+		draw.Draw(img, tile.Rect.Add(at), tile, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// ReadSheetMap reads a sheet mapping: one "index code-point" pair per line,
+// e.g. "0x41 U+0041" (anything after the code point is ignored, so an edited
+// listing can be read back). Indexes are decimal or 0x-prefixed hex; U+0000
+// leaves the tile blank. Blank lines and lines starting with '#' are skipped.
+func ReadSheetMap(path string) (map[int]rune, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading sheet mapping: %w", err)
+	}
+	mapping := map[int]rune{}
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: want a tile index and a code point", path, i+1)
+		}
+		index, err := strconv.ParseInt(fields[0], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid tile index %q", path, i+1, fields[0])
+		}
+		hex, ok := strings.CutPrefix(strings.ToUpper(fields[1]), "U+")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: code point %q must be written U+XXXX", path, i+1, fields[1])
+		}
+		r, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid code point %q", path, i+1, fields[1])
+		}
+		mapping[int(index)] = rune(r)
+	}
+	return mapping, nil
+}
+
+// writeSheetListing writes the tile index and code point of every sheet entry.
+func writeSheetListing(path, sheet string, runes []rune) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	if _, err := fmt.Fprintf(f, "# %s tileset: index (row-major, %d per row), code point, character\n", sheet, sheetColumns); err != nil {
+		return err
+	}
+	for i, r := range runes {
+		line := fmt.Sprintf("0x%02X U+%04X", i, r)
+		if r >= ' ' && utf8.ValidRune(r) {
+			line += " " + string(r)
+		}
+		if _, err := fmt.Fprintln(f, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package converter
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestCP437Table(t *testing.T) {
+	if len(cp437) != 256 {
+		t.Fatalf("%d entries, want 256", len(cp437))
+	}
+	for b, want := range map[int]rune{0x01: '☺', 0x1F: '▼', 0x41: 'A', 0x7F: '⌂', 0x80: 'Ç', 0xB0: '░', 0xDB: '█', 0xE1: 'ß', 0xFF: ' '} {
+		if cp437[b] != want {
+			t.Errorf("0x%02X maps to %q, want %q", b, cp437[b], want)
+		}
+	}
+}
+
+func TestSheet(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	prefix := filepath.Join(t.TempDir(), "font")
+	opts := Options{Size: 16, Format: "png", Sheet: SheetCP437, SheetListing: true, CellWidth: 10, Monospace: MonospaceLeft}
+	if _, err := GenerateWithStats(path, prefix, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(prefix + ".fnt"); !os.IsNotExist(err) {
+		t.Errorf("sheet wrote an FNT (%v)", err)
+	}
+
+	// 16x16 cells of 10 x lineHeight (19 for Go Regular at 16).
+	img := readPNG(t, prefix+".png")
+	if img.Bounds().Dx() != 160 || img.Bounds().Dy() != 16*19 {
+		t.Fatalf("sheet is %v, want 160x304", img.Bounds())
+	}
+	inked := func(index int) bool {
+		x0, y0 := index%16*10, index/16*19
+		for y := y0; y < y0+19; y++ {
+			for x := x0; x < x0+10; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, index := range []int{0x01, 0x41, 0xDB} {
+		if !inked(index) {
+			t.Errorf("tile 0x%02X is blank", index)
+		}
+	}
+	if inked(0x20) {
+		t.Error("the space tile has ink")
+	}
+
+	listing, _ := os.ReadFile(prefix + ".txt")
+	lines := strings.Split(strings.TrimSpace(string(listing)), "\n")
+	if len(lines) != 257 || lines[1+0x41] != "0x41 U+0041 A" || lines[1+0xDB] != "0xDB U+2588 █" {
+		t.Errorf("tile listing:\n%s", listing)
+	}
+
+	opts.FitWidth, opts.FitHeight = 256, 256
+	if err := GenerateWithOptions(path, prefix, opts); err == nil {
+		t.Error("expected an error for a fitted sheet")
+	}
+}
+
+func TestSheetMap(t *testing.T) {
+	dir := t.TempDir()
+	mapFile := filepath.Join(dir, "tiles.txt")
+	data := "# Test mapping\n\n0x01 U+0041 A\n65 u+0000\n  0xdb   U+2665 ♥ heart\n"
+	if err := os.WriteFile(mapFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := ReadSheetMap(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mapping) != 3 || mapping[0x01] != 'A' || mapping[0x41] != 0 || mapping[0xDB] != '♥' {
+		t.Fatalf("mapping %v", mapping)
+	}
+
+	for _, bad := range []string{"0x41", "x41 U+0041", "0x41 0041", "0x41 U+XYZ"} {
+		if err := os.WriteFile(mapFile, []byte(bad+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadSheetMap(mapFile); err == nil || !strings.Contains(err.Error(), ":1:") {
+			t.Errorf("%q: got %v, want an error for line 1", bad, err)
+		}
+	}
+
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	prefix := filepath.Join(dir, "font")
+	opts := Options{Size: 16, Format: "png", Sheet: SheetCP437, SheetListing: true, SheetMap: mapping, CellWidth: 10, Monospace: MonospaceLeft}
+	stats, err := GenerateWithStats(path, prefix, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Go Regular maps U+0000 to an empty glyph; it must not be one of the cells.
+	chars := sheetChars(sheetTable(SheetCP437, mapping))
+	if strings.ContainsRune(chars, 0) || stats.Glyphs+utf8.RuneCountInString(stats.Missing) != utf8.RuneCountInString(chars) {
+		t.Errorf("%d glyphs and %q missing for %d characters: U+0000 was looked up in the font", stats.Glyphs, stats.Missing, utf8.RuneCountInString(chars))
+	}
+
+	img := readPNG(t, prefix+".png").(*image.NRGBA)
+	tile := func(index int) *image.NRGBA {
+		return img.SubImage(image.Rect(index%16*10, index/16*19, index%16*10+10, index/16*19+19)).(*image.NRGBA)
+	}
+	blank := func(index int) bool {
+		m := tile(index)
+		for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+			for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+				if m.NRGBAAt(x, y).A != 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	if blank(0x01) || blank(0xDB) {
+		t.Error("remapped tiles are blank")
+	}
+	if !blank(0x00) || !blank(0x41) {
+		t.Error("tiles mapped to U+0000 have ink")
+	}
+
+	// Tile 0x01 holds what tile 0x41 holds without the mapping.
+	plainOpts := opts
+	plainOpts.SheetMap, plainOpts.SheetListing = nil, false
+	if err := GenerateWithOptions(path, filepath.Join(dir, "plain"), plainOpts); err != nil {
+		t.Fatal(err)
+	}
+	plain := readPNG(t, filepath.Join(dir, "plain.png")).(*image.NRGBA)
+	for y := 0; y < 19; y++ {
+		for x := 0; x < 10; x++ {
+			if got, want := img.NRGBAAt(0x01*10+x, y), plain.NRGBAAt(0x01*10+x, 4*19+y); got != want {
+				t.Fatalf("tile 0x01 (%d,%d) is %v, want %v as in tile 0x41", x, y, got, want)
+			}
+		}
+	}
+
+	listing, _ := os.ReadFile(prefix + ".txt")
+	lines := strings.Split(strings.TrimSpace(string(listing)), "\n")
+	if len(lines) != 257 || lines[1] != "0x00 U+0000" || lines[1+0x01] != "0x01 U+0041 A" || lines[1+0x41] != "0x41 U+0000" || lines[1+0xDB] != "0xDB U+2665 ♥" {
+		t.Errorf("tile listing:\n%s", listing)
+	}
+
+	// The listing reads back as a mapping of every tile.
+	if back, err := ReadSheetMap(prefix + ".txt"); err != nil || len(back) != 256 || back[0xDB] != '♥' {
+		t.Errorf("reading the listing back: %d entries, %v", len(back), err)
+	}
+
+	opts.SheetMap = map[int]rune{0x100: 'A'}
+	if err := GenerateWithOptions(path, prefix, opts); err == nil {
+		t.Error("expected an error for a tile out of range")
+	}
+	opts.Sheet, opts.SheetListing, opts.SheetMap = "", false, mapping
+	if err := GenerateWithOptions(path, prefix, opts); err == nil {
+		t.Error("expected an error for a mapping without a sheet")
+	}
+}
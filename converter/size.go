@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/image/font"
+)
+
+// Size modes: how Options.Size is interpreted.
+const (
+	SizePoints     = ""     // Points at Options.DPI (the em size)
+	SizeLineHeight = "line" // Target line height in pixels
+	SizeCapHeight  = "cap"  // Target cap height in pixels
+)
+
+const defaultDPI = 72
+
+// validateSize checks the size, DPI and size mode options.
+func validateSize(size, dpi float64, mode string) error {
+	if size <= 0 || size > 4096 {
+		return fmt.Errorf("size must be greater than 0 and at most 4096")
+	}
+	if dpi < 0 || dpi > 2400 {
+		return fmt.Errorf("dpi must be between 1 and 2400")
+	}
+	switch mode {
+	case SizePoints, SizeLineHeight, SizeCapHeight:
+		return nil
+	}
+	return fmt.Errorf("unknown size mode %q (use 'line' or 'cap')", mode)
+}
+
+// solveSize finds the largest point size whose line or cap height (in whole
+// pixels) does not exceed target, the way BMFont handles negative sizes.
+// newFace creates a face at a point size.
+func solveSize(newFace func(float64) (font.Face, error), mode string, target, dpi float64) (float64, error) {
+	measure := func(pt float64) (int, error) {
+		face, err := newFace(pt)
+		if err != nil {
+			return 0, err
+		}
+		defer face.Close()
+		if mode == SizeLineHeight {
+			return face.Metrics().Height.Ceil(), nil
+		}
+		if bounds, _, ok := face.GlyphBounds('H'); ok && bounds.Min.Y < 0 {
+			return (-bounds.Min.Y).Round(), nil
+		}
+		return face.Metrics().CapHeight.Round(), nil
+	}
+
+	// Start from "the em is the target" and grow until the target is exceeded.
+	lo, hi := 0.0, target*72/dpi
+	for {
+		m, err := measure(hi)
+		if err != nil {
+			return 0, err
+		}
+		if m > int(math.Round(target)) {
+			break
+		}
+		if m <= 0 && hi > target*72/dpi*64 {
+			return 0, errors.New("font has no usable line or cap height")
+		}
+		lo, hi = hi, hi*2
+	}
+
+	// Bisect to 1/64 point.
+	for hi-lo > 1.0/64 {
+		mid := (lo + hi) / 2
+		m, err := measure(mid)
+		if err != nil {
+			return 0, err
+		}
+		if m > int(math.Round(target)) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	if lo == 0 {
+		return 0, fmt.Errorf("no font size gives a %s height of %gpx", mode, target)
+	}
+	return lo, nil
+}
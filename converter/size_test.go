@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestSizeModes(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	render := func(opts Options) (info, common string, capHeight int) {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "font")
+		opts.Chars, opts.Format = "H", "png"
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		lines := strings.Split(string(fnt), "\n")
+
+		// Measure the ink height of 'H' in the atlas.
+		img := readPNG(t, prefix+".png")
+		top, bottom := -1, -1
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a>>8 >= 128 {
+					if top < 0 {
+						top = y
+					}
+					bottom = y
+				}
+			}
+		}
+		return lines[0], lines[1], bottom - top + 1
+	}
+
+	// 12pt at 144 DPI is a 24px em.
+	info, common, _ := render(Options{Size: 12, DPI: 144})
+	_, want, _ := render(Options{Size: 24})
+	if !strings.Contains(info, " size=24 ") || common != want {
+		t.Errorf("12pt@144dpi: %s / %s, want size=24 and %s", info, common, want)
+	}
+
+	// Fractional sizes are accepted.
+	if info, _, _ := render(Options{Size: 10.5}); !strings.Contains(info, " size=11 ") {
+		t.Errorf("10.5pt: %s", info)
+	}
+
+	for _, target := range []int{16, 24, 37} {
+		info, common, _ := render(Options{Size: float64(target), SizeMode: SizeLineHeight})
+		if !strings.Contains(common, fmt.Sprintf("lineHeight=%d ", target)) || !strings.Contains(info, fmt.Sprintf(" size=-%d ", target)) {
+			t.Errorf("line %d: %s / %s", target, info, common)
+		}
+
+		_, _, capHeight := render(Options{Size: float64(target), SizeMode: SizeCapHeight, Hinting: "full"})
+		if capHeight != target {
+			t.Errorf("cap %d: 'H' is %dpx tall", target, capHeight)
+		}
+	}
+
+	if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "bad"), Options{Size: 16, Chars: "H", Format: "png", SizeMode: "em"}); err == nil {
+		t.Error("expected an error for an unknown size mode")
+	}
+}
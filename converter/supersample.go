@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Supersampling renders each glyph cell at N times the size and filters it
+// back down. Cell geometry (position, width, baseline) always comes from the
+// target-size face, so the FNT is identical to the unsupersampled output.
+
+const maxSupersample = 16
+
+// validateSupersample checks the supersampling factor and filter name.
+func validateSupersample(n int, filter string) error {
+	if n < 0 || n > maxSupersample {
+		return fmt.Errorf("supersample factor must be between 1 and %d", maxSupersample)
+	}
+	switch filter {
+	case "", "box", "lanczos":
+		return nil
+	}
+	return fmt.Errorf("unknown supersample filter %q (use 'box' or 'lanczos')", filter)
+}
+
+// drawSupersampled renders char with the n-times-larger face hiFace and
+// filters it into the width x height cell of dst at (x, y), with the pen
+// dotX pixels into the cell. ascent is the baseline of the target-size face.
+func drawSupersampled(dst *image.RGBA, hiFace font.Face, char rune, x, y, dotX, width, height, ascent, n int, filter string) {
+	hi := image.NewAlpha(image.Rect(0, 0, width*n, height*n))
+	d := &font.Drawer{
+		Dst:  hi,
+		Src:  image.White,
+		Face: hiFace,
+		Dot:  fixed.P(dotX*n, ascent*n),
+	}
+	d.DrawString(string(char))
+
+	var lo *image.Alpha
+	if filter == "lanczos" {
+		lo = downsampleLanczos(hi, n)
+	} else {
+		lo = downsampleBox(hi, n)
+	}
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			a := lo.AlphaAt(px, py).A
+			if a == 0 {
+				continue
+			}
+			// Premultiplied white, as font.Drawer produces over transparency.
+			dst.SetRGBA(x+px, y+py, color.RGBA{R: a, G: a, B: a, A: a})
+		}
+	}
+}
+
+// downsampleBox averages each n x n block of src.
+func downsampleBox(src *image.Alpha, n int) *image.Alpha {
+	w, h := src.Bounds().Dx()/n, src.Bounds().Dy()/n
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	area := n * n
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum := 0
+			for sy := y * n; sy < (y+1)*n; sy++ {
+				row := src.Pix[sy*src.Stride+x*n : sy*src.Stride+(x+1)*n]
+				for _, a := range row {
+					sum += int(a)
+				}
+			}
+			dst.Pix[y*dst.Stride+x] = uint8((sum + area/2) / area)
+		}
+	}
+	return dst
+}
+
+// downsampleLanczos reduces src by n using a separable Lanczos-3 filter
+// (scaled by n so that it also acts as the low-pass filter).
+func downsampleLanczos(src *image.Alpha, n int) *image.Alpha {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	w, h := sw/n, sh/n
+	weights := lanczosWeights(n)
+	radius := 3 * n
+
+	// Horizontal pass: sw x sh -> w x sh.
+	tmp := make([]float64, w*sh)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+sw]
+		for x := 0; x < w; x++ {
+			tmp[y*w+x] = convolve(func(i int) float64 { return float64(row[i]) }, x*n, radius, sw, weights)
+		}
+	}
+
+	// Vertical pass: w x sh -> w x h.
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			v := convolve(func(i int) float64 { return tmp[i*w+x] }, y*n, radius, sh, weights)
+			dst.Pix[y*dst.Stride+x] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+		}
+	}
+	return dst
+}
+
+// lanczosWeights returns the filter taps for one output pixel: tap k covers
+// source pixel (start - 3n + k), where start begins the output's n-pixel block.
+func lanczosWeights(n int) []float64 {
+	taps := make([]float64, 7*n)
+	var sum float64
+	for k := range taps {
+		// Distance from the source pixel centre to the output pixel centre,
+		// in output pixels.
+		d := (float64(k-3*n) + 0.5 - float64(n)/2) / float64(n)
+		taps[k] = lanczos3(d)
+		sum += taps[k]
+	}
+	for k := range taps {
+		taps[k] /= sum
+	}
+	return taps
+}
+
+func lanczos3(x float64) float64 {
+	switch {
+	case x == 0:
+		return 1
+	case x <= -3 || x >= 3:
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
+
+// convolve applies the taps around the n-pixel block starting at start.
+// Pixels outside [0, size) are transparent.
+func convolve(at func(int) float64, start, radius, size int, taps []float64) float64 {
+	var sum float64
+	for k, wgt := range taps {
+		if i := start - radius + k; i >= 0 && i < size {
+			sum += at(i) * wgt
+		}
+	}
+	return sum
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestSupersample(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+	plainPrefix := filepath.Join(t.TempDir(), "font")
+	opts := Options{Size: 12, Chars: "AgW", Format: "png", Spacing: [2]int{2, 2}, Hinting: "none"}
+	if err := GenerateWithOptions(path, plainPrefix, opts); err != nil {
+		t.Fatal(err)
+	}
+	plain := readPNG(t, plainPrefix+".png")
+
+	for _, filter := range []string{"box", "lanczos"} {
+		opts.Supersample, opts.SupersampleFilter = 4, filter
+		prefix := filepath.Join(t.TempDir(), "font")
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatalf("%s: %v", filter, err)
+		}
+
+		// Layout comes from the target size, so the FNT must not change.
+		want, _ := os.ReadFile(plainPrefix + ".fnt")
+		got, _ := os.ReadFile(prefix + ".fnt")
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: FNT differs from the unsupersampled output", filter)
+		}
+
+		// Coverage should roughly match the direct rendering.
+		img := readPNG(t, prefix+".png")
+		if img.Bounds() != plain.Bounds() {
+			t.Fatalf("%s: size %v, want %v", filter, img.Bounds(), plain.Bounds())
+		}
+		if got, want := coverage(img), coverage(plain); got < want*8/10 || got > want*12/10 {
+			t.Errorf("%s: coverage %d, want about %d", filter, got, want)
+		}
+	}
+
+	opts.SupersampleFilter = "bicubic"
+	if err := GenerateWithOptions(path, filepath.Join(t.TempDir(), "bad"), opts); err == nil {
+		t.Error("expected an error for an unknown filter")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	// A solid 4x4 block in an 8x4 image reduces to one opaque and one empty pixel.
+	src := image.NewAlpha(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.Pix[y*src.Stride+x] = 255
+		}
+	}
+	box := downsampleBox(src, 4)
+	if box.Pix[0] != 255 || box.Pix[1] != 0 {
+		t.Errorf("box: %v, want [255 0]", box.Pix)
+	}
+
+	// Lanczos low-passes edges, so check the inside and outside of a larger square.
+	src = image.NewAlpha(image.Rect(0, 0, 48, 48))
+	for y := 8; y < 40; y++ {
+		for x := 8; x < 40; x++ {
+			src.Pix[y*src.Stride+x] = 255
+		}
+	}
+	lz := downsampleLanczos(src, 4)
+	if in, out := lz.AlphaAt(6, 6).A, lz.AlphaAt(0, 0).A; in < 250 || out > 5 {
+		t.Errorf("lanczos: inside %d outside %d, want about 255 and 0", in, out)
+	}
+}
+
+func readPNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// coverage sums the alpha of every pixel.
+func coverage(img image.Image) int {
+	sum := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			_, _, _, a := img.At(x, y).RGBA()
+			sum += int(a >> 8)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,168 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Synthetic styles for families without a bold or italic file. Bold dilates
+// the glyph mask to the right by a fractional number of pixels (and widens
+// the advance by as much); oblique shears the mask around the baseline.
+
+const maxOblique = 45
+
+// validateSynthetic checks the emboldening strength and oblique angle.
+func validateSynthetic(embolden, oblique float64) error {
+	if embolden < 0 || embolden > 16 {
+		return fmt.Errorf("embolden must be between 0 and 16 pixels")
+	}
+	if oblique < -maxOblique || oblique > maxOblique {
+		return fmt.Errorf("oblique angle must be between -%d and %d degrees", maxOblique, maxOblique)
+	}
+	return nil
+}
+
+// syntheticFace wraps a face, emboldening and/or shearing its glyphs.
+type syntheticFace struct {
+	font.Face
+	embolden float64 // Extra stem width in pixels
+	shear    float64 // Horizontal shift per pixel above the baseline
+}
+
+// newSyntheticFace wraps face unless no synthetic style is requested.
+// embolden is in pixels at this face's size; oblique is in degrees.
+func newSyntheticFace(face font.Face, embolden, oblique float64) font.Face {
+	if embolden == 0 && oblique == 0 {
+		return face
+	}
+	return &syntheticFace{Face: face, embolden: embolden, shear: math.Tan(oblique * math.Pi / 180)}
+}
+
+// obliqueOverhang returns how far (in whole pixels) sheared glyphs spill left and
+// right of their advance within a line of the given ascent and descent.
+func obliqueOverhang(oblique float64, ascent, descent int) (left, right int) {
+	shear := math.Tan(oblique * math.Pi / 180)
+	top, bottom := shear*float64(ascent), -shear*float64(descent)
+	left = int(math.Ceil(math.Max(0, math.Max(-top, -bottom))))
+	right = int(math.Ceil(math.Max(0, math.Max(top, bottom))))
+	return left, right
+}
+
+func (s *syntheticFace) extra() fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(s.embolden * 64))
+}
+
+func (s *syntheticFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, advance, ok := s.Face.Glyph(dot, r)
+	if !ok {
+		return dr, mask, maskp, advance, ok
+	}
+	advance += s.extra()
+	if dr.Empty() {
+		return dr, mask, maskp, advance, ok
+	}
+
+	// Copy the mask into an Alpha indexed by destination coordinates.
+	src := image.NewAlpha(dr)
+	for y := dr.Min.Y; y < dr.Max.Y; y++ {
+		for x := dr.Min.X; x < dr.Max.X; x++ {
+			_, _, _, a := mask.At(maskp.X+x-dr.Min.X, maskp.Y+y-dr.Min.Y).RGBA()
+			src.Pix[src.PixOffset(x, y)] = uint8(a >> 8)
+		}
+	}
+
+	if s.embolden > 0 {
+		src = dilate(src, s.embolden)
+	}
+	if s.shear != 0 {
+		src = shear(src, s.shear, dot.Y.Round())
+	}
+	return src.Rect, src, src.Rect.Min, advance, true
+}
+
+func (s *syntheticFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	bounds, advance, ok := s.Face.GlyphBounds(r)
+	if !ok {
+		return bounds, advance, ok
+	}
+	bounds.Max.X += s.extra()
+	if s.shear != 0 {
+		// Points above the baseline (negative y) move right.
+		top := fixed.Int26_6(math.Round(-float64(bounds.Min.Y) * s.shear))
+		bottom := fixed.Int26_6(math.Round(-float64(bounds.Max.Y) * s.shear))
+		bounds.Min.X += min(top, bottom)
+		bounds.Max.X += max(top, bottom)
+	}
+	return bounds, advance + s.extra(), true
+}
+
+func (s *syntheticFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	advance, ok := s.Face.GlyphAdvance(r)
+	return advance + s.extra(), ok
+}
+
+// dilate smears the mask to the right by strength pixels: each pixel takes
+// the maximum of its left neighbours within that distance, the farthest one
+// weighted by the fractional part.
+func dilate(src *image.Alpha, strength float64) *image.Alpha {
+	whole := int(strength)
+	frac := strength - float64(whole)
+	reach := whole
+	if frac > 0 {
+		reach++
+	}
+	r := src.Rect
+	dst := image.NewAlpha(image.Rect(r.Min.X, r.Min.Y, r.Max.X+reach, r.Max.Y))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < dst.Rect.Max.X; x++ {
+			var v float64
+			for k := 0; k <= reach; k++ {
+				sx := x - k
+				if sx < r.Min.X || sx >= r.Max.X {
+					continue
+				}
+				a := float64(src.Pix[src.PixOffset(sx, y)])
+				if k > whole {
+					a *= frac
+				}
+				v = math.Max(v, a)
+			}
+			dst.Pix[dst.PixOffset(x, y)] = uint8(math.Round(v))
+		}
+	}
+	return dst
+}
+
+// shear slants the mask: a row d pixels above the baseline moves right by
+// d*factor pixels, with linear interpolation between source pixels.
+func shear(src *image.Alpha, factor float64, baseline int) *image.Alpha {
+	r := src.Rect
+	// Rows are sampled at their centres.
+	offset := func(y int) float64 { return (float64(baseline) - (float64(y) + 0.5)) * factor }
+	lo := math.Min(offset(r.Min.Y), offset(r.Max.Y-1))
+	hi := math.Max(offset(r.Min.Y), offset(r.Max.Y-1))
+	dst := image.NewAlpha(image.Rect(r.Min.X+int(math.Floor(lo)), r.Min.Y, r.Max.X+int(math.Ceil(hi)), r.Max.Y))
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		off := offset(y)
+		for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+			// Source position of this pixel, split into two neighbours.
+			sx := float64(x) - off
+			x0 := int(math.Floor(sx))
+			t := sx - float64(x0)
+			at := func(x int) float64 {
+				if x < r.Min.X || x >= r.Max.X {
+					return 0
+				}
+				return float64(src.Pix[src.PixOffset(x, y)])
+			}
+			v := at(x0)*(1-t) + at(x0+1)*t
+			dst.Pix[dst.PixOffset(x, y)] = uint8(math.Round(v))
+		}
+	}
+	return dst
+}
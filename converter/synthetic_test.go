@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestSyntheticStyles(t *testing.T) {
+	path := writeTempFont(t, "Go-Regular.ttf", goregular.TTF)
+
+	render := func(embolden, oblique float64) (string, image.Image) {
+		t.Helper()
+		prefix := filepath.Join(t.TempDir(), "font")
+		opts := Options{Size: 32, Chars: "Hl", Format: "png", Spacing: [2]int{2, 2}, Embolden: embolden, Oblique: oblique}
+		if err := GenerateWithOptions(path, prefix, opts); err != nil {
+			t.Fatal(err)
+		}
+		fnt, _ := os.ReadFile(prefix + ".fnt")
+		return string(fnt), readPNG(t, prefix+".png")
+	}
+
+	plain, _ := render(0, 0)
+	fnt, img := render(1, 12)
+	if !strings.Contains(fnt, " bold=1 italic=1 ") {
+		t.Errorf("info line lacks bold=1 italic=1:\n%s", fnt)
+	}
+
+	// 12 degrees over Go's 31px ascent and 6px descent: 7px right, 2px left.
+	var x, w, xoff, adv, plainAdv int
+	if _, err := fmt.Sscanf(charLine(t, fnt, 'H'), "char id=72 x=%d y=0 width=%d height=37 xoffset=%d yoffset=0 xadvance=%d", &x, &w, &xoff, &adv); err != nil {
+		t.Fatalf("parsing char line: %v", err)
+	}
+	plainLine := charLine(t, plain, 'H')
+	if _, err := fmt.Sscanf(plainLine[strings.Index(plainLine, "xadvance="):], "xadvance=%d", &plainAdv); err != nil {
+		t.Fatal(err)
+	}
+	if adv != plainAdv+1 {
+		t.Errorf("xadvance = %d, want %d (emboldened by 1px)", adv, plainAdv+1)
+	}
+	if xoff != -2 || w != 2+adv+7 {
+		t.Errorf("xoffset=%d width=%d, want -2 and %d", xoff, w, 2+adv+7)
+	}
+
+	// Nothing may be drawn in the spacing between cells.
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for px := x + w; px < x+w+2; px++ {
+			if _, _, _, a := img.At(px, y).RGBA(); a != 0 {
+				t.Fatalf("ink in the spacing at (%d,%d)", px, y)
+			}
+		}
+	}
+}
+
+func charLine(t *testing.T, fnt string, r rune) string {
+	t.Helper()
+	prefix := fmt.Sprintf("char id=%d ", r)
+	for _, l := range strings.Split(fnt, "\n") {
+		if strings.HasPrefix(l, prefix) {
+			return l
+		}
+	}
+	t.Fatalf("no char line for %q", r)
+	return ""
+}
+
+func TestDilateShear(t *testing.T) {
+	// A single opaque pixel at (0,-1), just above the baseline at y=0.
+	src := image.NewAlpha(image.Rect(0, -1, 1, 0))
+	src.Pix[0] = 255
+
+	d := dilate(src, 1.5)
+	if d.Rect != image.Rect(0, -1, 3, 0) || d.Pix[0] != 255 || d.Pix[1] != 255 || d.Pix[2] != 128 {
+		t.Errorf("dilate: rect %v pix %v", d.Rect, d.Pix)
+	}
+
+	// The row centre is 0.5px above the baseline: a shear of 1 moves it by half a pixel.
+	s := shear(src, 1, 0)
+	sum := 0
+	for x := s.Rect.Min.X; x < s.Rect.Max.X; x++ {
+		sum += int(s.AlphaAt(x, -1).A)
+	}
+	if s.AlphaAt(0, -1).A != 128 || s.AlphaAt(1, -1).A != 128 || sum != 256 {
+		t.Errorf("shear: rect %v pix %v", s.Rect, s.Pix)
+	}
+}
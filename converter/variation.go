@@ -0,0 +1,1127 @@
+package converter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Variable font support: picks an instance from the fvar design space and
+// applies the avar/gvar/HVAR variations when outlines and advances are
+// computed. Only TrueType outlines (glyf) are supported; CFF2 is not.
+//
+// Spec: https://learn.microsoft.com/en-us/typography/opentype/spec/otvaroverview
+
+// AxisValue sets one design axis of a variable font, e.g. wght=700.
+type AxisValue struct {
+	Tag   string
+	Value float64
+}
+
+// ParseAxes parses an axis list such as "wght=700,wdth=85".
+func ParseAxes(s string) ([]AxisValue, error) {
+	var axes []AxisValue
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, val, ok := strings.Cut(part, "=")
+		tag = strings.TrimSpace(tag)
+		if !ok || tag == "" {
+			return nil, fmt.Errorf("invalid axis setting %q (use e.g. 'wght=700')", part)
+		}
+		if err := validateAxisTag(tag); err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid axis value in %q: %w", part, err)
+		}
+		axes = append(axes, AxisValue{Tag: tag, Value: v})
+	}
+	return axes, nil
+}
+
+// validateAxisTag checks that tag can name an fvar axis: exactly four
+// printable ASCII characters (e.g. "wght", "opsz").
+func validateAxisTag(tag string) error {
+	if len(tag) != 4 {
+		return fmt.Errorf("invalid axis tag %q (axis tags are 4 characters, e.g. 'wght')", tag)
+	}
+	for i := 0; i < len(tag); i++ {
+		if tag[i] < 0x20 || tag[i] > 0x7e {
+			return fmt.Errorf("invalid axis tag %q (axis tags are 4 characters, e.g. 'wght')", tag)
+		}
+	}
+	return nil
+}
+
+// VariationName describes an instance for file names and the FNT face
+// attribute: the named instance if given, followed by any explicit axis
+// settings (e.g. "Bold Condensed" or "wght700 wdth85").
+func VariationName(namedInstance string, axes []AxisValue) string {
+	parts := []string{}
+	if namedInstance != "" {
+		parts = append(parts, namedInstance)
+	}
+	for _, a := range axes {
+		parts = append(parts, a.Tag+strconv.FormatFloat(a.Value, 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+var errNotVariable = withKind(ErrInvalidOptions, errors.New("font has no variation axes (fvar table)"))
+
+// fvarAxis is a design axis in user-space units.
+type fvarAxis struct {
+	tag           string
+	min, def, max float64
+}
+
+// fvarInstance is a named instance: a name plus a user-space coordinate per axis.
+type fvarInstance struct {
+	nameID uint16
+	coords []float64
+}
+
+// parseFvar reads the axes and named instances of a variable font.
+func parseFvar(b []byte) ([]fvarAxis, []fvarInstance, error) {
+	if len(b) < 16 {
+		return nil, nil, errNotVariable
+	}
+	axesOffset := int(binary.BigEndian.Uint16(b[4:]))
+	axisCount := int(binary.BigEndian.Uint16(b[8:]))
+	axisSize := int(binary.BigEndian.Uint16(b[10:]))
+	instanceCount := int(binary.BigEndian.Uint16(b[12:]))
+	instanceSize := int(binary.BigEndian.Uint16(b[14:]))
+	if axisSize < 20 || instanceSize < 4+4*axisCount || axesOffset+axisCount*axisSize+instanceCount*instanceSize > len(b) {
+		return nil, nil, errors.New("invalid fvar table")
+	}
+
+	fixed16 := func(p []byte) float64 { return float64(int32(binary.BigEndian.Uint32(p))) / 65536 }
+
+	axes := make([]fvarAxis, axisCount)
+	for i := range axes {
+		rec := b[axesOffset+i*axisSize:]
+		axes[i] = fvarAxis{
+			tag: string(rec[:4]),
+			min: fixed16(rec[4:]),
+			def: fixed16(rec[8:]),
+			max: fixed16(rec[12:]),
+		}
+	}
+
+	instances := make([]fvarInstance, instanceCount)
+	for i := range instances {
+		rec := b[axesOffset+axisCount*axisSize+i*instanceSize:]
+		inst := fvarInstance{nameID: binary.BigEndian.Uint16(rec), coords: make([]float64, axisCount)}
+		for j := range inst.coords {
+			inst.coords[j] = fixed16(rec[4+4*j:])
+		}
+		instances[i] = inst
+	}
+	return axes, instances, nil
+}
+
+// normalize maps user-space coordinates to the normalized [-1, 1] design
+// space, applying the avar segment maps if the font has them.
+func normalize(axes []fvarAxis, user []float64, avar []byte) []float64 {
+	coords := make([]float64, len(axes))
+	for i, a := range axes {
+		v := math.Max(a.min, math.Min(a.max, user[i]))
+		switch {
+		case v < a.def && a.def > a.min:
+			coords[i] = (v - a.def) / (a.def - a.min)
+		case v > a.def && a.max > a.def:
+			coords[i] = (v - a.def) / (a.max - a.def)
+		}
+	}
+
+	if len(avar) >= 8 && int(binary.BigEndian.Uint16(avar[6:])) == len(axes) {
+		r := &woffReader{b: avar, pos: 8}
+		for i := range coords {
+			n, err := r.u16()
+			if err != nil {
+				break
+			}
+			maps := make([][2]float64, 0, n)
+			for j := 0; j < int(n); j++ {
+				from, err1 := r.u16()
+				to, err2 := r.u16()
+				if errors.Join(err1, err2) != nil {
+					return coords
+				}
+				maps = append(maps, [2]float64{f2dot14(from), f2dot14(to)})
+			}
+			coords[i] = applySegmentMap(maps, coords[i])
+		}
+	}
+
+	// Normalized coordinates are F2Dot14 values.
+	for i := range coords {
+		coords[i] = math.Round(coords[i]*16384) / 16384
+	}
+	return coords
+}
+
+func f2dot14(v uint16) float64 { return float64(int16(v)) / 16384 }
+
+// applySegmentMap evaluates a piecewise-linear avar mapping at v.
+func applySegmentMap(maps [][2]float64, v float64) float64 {
+	if len(maps) == 0 {
+		return v
+	}
+	if v <= maps[0][0] {
+		return v + maps[0][1] - maps[0][0]
+	}
+	for i := 1; i < len(maps); i++ {
+		if v <= maps[i][0] {
+			lo, hi := maps[i-1], maps[i]
+			if hi[0] == lo[0] {
+				return hi[1]
+			}
+			return lo[1] + (hi[1]-lo[1])*(v-lo[0])/(hi[0]-lo[0])
+		}
+	}
+	last := maps[len(maps)-1]
+	return v + last[1] - last[0]
+}
+
+// regionScalar is the contribution of a variation region (or tuple) with the
+// given start/peak/end per axis at the normalized coordinates.
+func regionScalar(coords, start, peak, end []float64) float64 {
+	scalar := 1.0
+	for i, p := range peak {
+		if p == 0 {
+			continue
+		}
+		v := coords[i]
+		if start == nil {
+			// Implicit region: from 0 to the peak.
+			if v == 0 || v < math.Min(0, p) || v > math.Max(0, p) {
+				return 0
+			}
+			scalar *= v / p
+			continue
+		}
+		s, e := start[i], end[i]
+		if s > p || p > e || (s < 0 && e > 0) {
+			continue
+		}
+		switch {
+		case v < s || v > e:
+			return 0
+		case v < p:
+			scalar *= (v - s) / (p - s)
+		case v > p:
+			scalar *= (e - v) / (e - p)
+		}
+	}
+	return scalar
+}
+
+// gvarTable holds the glyph variation data of a variable TrueType font.
+type gvarTable struct {
+	b            []byte
+	axisCount    int
+	sharedTuples [][]float64
+	glyphCount   int
+	longOffsets  bool
+	dataOffset   int
+}
+
+func parseGvar(b []byte) (*gvarTable, error) {
+	if len(b) < 20 {
+		return nil, errors.New("invalid gvar table")
+	}
+	t := &gvarTable{
+		b:           b,
+		axisCount:   int(binary.BigEndian.Uint16(b[4:])),
+		glyphCount:  int(binary.BigEndian.Uint16(b[12:])),
+		longOffsets: binary.BigEndian.Uint16(b[14:])&1 != 0,
+		dataOffset:  int(binary.BigEndian.Uint32(b[16:])),
+	}
+	sharedCount := int(binary.BigEndian.Uint16(b[6:]))
+	sharedOffset := int(binary.BigEndian.Uint32(b[8:]))
+	if sharedOffset+2*t.axisCount*sharedCount > len(b) {
+		return nil, errors.New("invalid gvar table")
+	}
+	for i := 0; i < sharedCount; i++ {
+		tuple := make([]float64, t.axisCount)
+		for j := range tuple {
+			tuple[j] = f2dot14(binary.BigEndian.Uint16(b[sharedOffset+2*(i*t.axisCount+j):]))
+		}
+		t.sharedTuples = append(t.sharedTuples, tuple)
+	}
+	return t, nil
+}
+
+// glyphData returns the variation data of glyph g (nil if it has none).
+func (t *gvarTable) glyphData(g int) []byte {
+	if g < 0 || g >= t.glyphCount {
+		return nil
+	}
+	var start, end int
+	if t.longOffsets {
+		if 20+4*(g+2) > len(t.b) {
+			return nil
+		}
+		start, end = int(binary.BigEndian.Uint32(t.b[20+4*g:])), int(binary.BigEndian.Uint32(t.b[24+4*g:]))
+	} else {
+		if 20+2*(g+2) > len(t.b) {
+			return nil
+		}
+		start, end = 2*int(binary.BigEndian.Uint16(t.b[20+2*g:])), 2*int(binary.BigEndian.Uint16(t.b[22+2*g:]))
+	}
+	start, end = start+t.dataOffset, end+t.dataOffset
+	if start >= end || end > len(t.b) {
+		return nil
+	}
+	return t.b[start:end]
+}
+
+// vpoint is an outline point in (fractional) font units.
+type vpoint struct {
+	x, y    float64
+	onCurve bool
+}
+
+// applyDeltas adds the variation deltas of glyph g to pts in place. For simple
+// glyphs, endPts delimits the contours used to infer the deltas of points a
+// tuple doesn't mention (IUP); composite glyphs pass nil.
+// pts always ends with the four phantom points.
+func (t *gvarTable) applyDeltas(g int, coords []float64, pts []vpoint, endPts []int) error {
+	data := t.glyphData(g)
+	if len(data) < 4 {
+		return nil
+	}
+	errGvar := errors.New("invalid gvar glyph data")
+
+	countWord := binary.BigEndian.Uint16(data)
+	count := int(countWord & 0x0fff)
+	serialized := &woffReader{b: data, pos: int(binary.BigEndian.Uint16(data[2:]))}
+	headers := &woffReader{b: data, pos: 4}
+
+	var sharedPoints []int
+	if countWord&0x8000 != 0 {
+		var err error
+		if sharedPoints, err = serialized.packedPoints(); err != nil {
+			return err
+		}
+	}
+
+	orig := append([]vpoint(nil), pts...)
+	for i := 0; i < count; i++ {
+		size, err1 := headers.u16()
+		index, err2 := headers.u16()
+		if errors.Join(err1, err2) != nil {
+			return errGvar
+		}
+
+		readTuple := func() ([]float64, error) {
+			tuple := make([]float64, t.axisCount)
+			for j := range tuple {
+				v, err := headers.u16()
+				if err != nil {
+					return nil, err
+				}
+				tuple[j] = f2dot14(v)
+			}
+			return tuple, nil
+		}
+
+		var peak, start, end []float64
+		var err error
+		if index&0x8000 != 0 {
+			if peak, err = readTuple(); err != nil {
+				return errGvar
+			}
+		} else if int(index&0x0fff) < len(t.sharedTuples) {
+			peak = t.sharedTuples[index&0x0fff]
+		} else {
+			return errGvar
+		}
+		if index&0x4000 != 0 {
+			start, err1 = readTuple()
+			end, err2 = readTuple()
+			if errors.Join(err1, err2) != nil {
+				return errGvar
+			}
+		}
+
+		tupleData, err := serialized.bytes(int(size))
+		if err != nil {
+			return errGvar
+		}
+		scalar := regionScalar(coords, start, peak, end)
+		if scalar == 0 {
+			continue
+		}
+
+		r := &woffReader{b: tupleData}
+		points := sharedPoints
+		if index&0x2000 != 0 {
+			if points, err = r.packedPoints(); err != nil {
+				return err
+			}
+		}
+		n := len(points)
+		if points == nil {
+			n = len(pts)
+		}
+		dx, err1 := r.packedDeltas(n)
+		dy, err2 := r.packedDeltas(n)
+		if errors.Join(err1, err2) != nil {
+			return errGvar
+		}
+
+		if points == nil {
+			for j := range pts {
+				pts[j].x += scalar * dx[j]
+				pts[j].y += scalar * dy[j]
+			}
+			continue
+		}
+
+		deltas := make([]vpoint, len(pts))
+		touched := make([]bool, len(pts))
+		for j, p := range points {
+			if p < len(pts) {
+				deltas[p] = vpoint{x: dx[j], y: dy[j]}
+				touched[p] = true
+			}
+		}
+		if endPts != nil {
+			interpolateUntouched(orig, deltas, touched, endPts)
+		}
+		for j := range pts {
+			pts[j].x += scalar * deltas[j].x
+			pts[j].y += scalar * deltas[j].y
+		}
+	}
+	return nil
+}
+
+// interpolateUntouched infers deltas for the points a tuple doesn't mention,
+// contour by contour (the IUP step of the gvar spec).
+func interpolateUntouched(orig, deltas []vpoint, touched []bool, endPts []int) {
+	start := 0
+	for _, end := range endPts {
+		var refs []int
+		for i := start; i <= end; i++ {
+			if touched[i] {
+				refs = append(refs, i)
+			}
+		}
+		switch len(refs) {
+		case 0:
+		case 1:
+			for i := start; i <= end; i++ {
+				deltas[i] = deltas[refs[0]]
+			}
+		default:
+			for k, r1 := range refs {
+				r2 := refs[(k+1)%len(refs)]
+				for i := r1 + 1; ; i++ {
+					if i > end {
+						i = start
+					}
+					if i == r2 {
+						break
+					}
+					deltas[i].x = iup(orig[i].x, orig[r1].x, orig[r2].x, deltas[r1].x, deltas[r2].x)
+					deltas[i].y = iup(orig[i].y, orig[r1].y, orig[r2].y, deltas[r1].y, deltas[r2].y)
+				}
+			}
+		}
+		start = end + 1
+	}
+}
+
+// iup interpolates the delta of coordinate v between reference coordinates
+// c1 and c2 with deltas d1 and d2.
+func iup(v, c1, c2, d1, d2 float64) float64 {
+	if c1 > c2 {
+		c1, c2, d1, d2 = c2, c1, d2, d1
+	}
+	switch {
+	case c1 == c2:
+		if d1 == d2 {
+			return d1
+		}
+		return 0
+	case v <= c1:
+		return d1
+	case v >= c2:
+		return d2
+	}
+	return d1 + (d2-d1)*(v-c1)/(c2-c1)
+}
+
+// packedPoints reads gvar packed point numbers; nil means "all points".
+func (r *woffReader) packedPoints() ([]int, error) {
+	errPoints := errors.New("invalid gvar point numbers")
+	b, err := r.u8()
+	if err != nil {
+		return nil, errPoints
+	}
+	count := int(b)
+	if b&0x80 != 0 {
+		lo, err := r.u8()
+		if err != nil {
+			return nil, errPoints
+		}
+		count = int(b&0x7f)<<8 | int(lo)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	points := make([]int, 0, count)
+	p := 0
+	for len(points) < count {
+		ctrl, err := r.u8()
+		if err != nil {
+			return nil, errPoints
+		}
+		for n := int(ctrl&0x7f) + 1; n > 0 && len(points) < count; n-- {
+			var d int
+			if ctrl&0x80 != 0 {
+				v, err := r.u16()
+				if err != nil {
+					return nil, errPoints
+				}
+				d = int(v)
+			} else {
+				v, err := r.u8()
+				if err != nil {
+					return nil, errPoints
+				}
+				d = int(v)
+			}
+			p += d
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+// packedDeltas reads n gvar packed deltas.
+func (r *woffReader) packedDeltas(n int) ([]float64, error) {
+	errDeltas := errors.New("invalid gvar deltas")
+	deltas := make([]float64, 0, n)
+	for len(deltas) < n {
+		ctrl, err := r.u8()
+		if err != nil {
+			return nil, errDeltas
+		}
+		for run := int(ctrl&0x3f) + 1; run > 0 && len(deltas) < n; run-- {
+			switch ctrl & 0xc0 {
+			case 0x80: // Zero
+				deltas = append(deltas, 0)
+			case 0x40: // Words
+				v, err := r.u16()
+				if err != nil {
+					return nil, errDeltas
+				}
+				deltas = append(deltas, float64(int16(v)))
+			case 0xc0: // Longs
+				v, err := r.u32()
+				if err != nil {
+					return nil, errDeltas
+				}
+				deltas = append(deltas, float64(int32(v)))
+			default: // Bytes
+				v, err := r.u8()
+				if err != nil {
+					return nil, errDeltas
+				}
+				deltas = append(deltas, float64(int8(v)))
+			}
+		}
+	}
+	return deltas, nil
+}
+
+// itemVariationStore is the delta store shared by HVAR (and MVAR etc).
+type itemVariationStore struct {
+	regions [][3][]float64 // start, peak, end per axis
+	data    []itemVariationData
+}
+
+type itemVariationData struct {
+	regionIndexes []int
+	deltas        [][]float64 // Per item, one delta per region index
+}
+
+func parseItemVariationStore(b []byte) (*itemVariationStore, error) {
+	errStore := errors.New("invalid item variation store")
+	if len(b) < 8 {
+		return nil, errStore
+	}
+	s := &itemVariationStore{}
+
+	regionsOffset := int(binary.BigEndian.Uint32(b[2:]))
+	if regionsOffset+4 > len(b) {
+		return nil, errStore
+	}
+	axisCount := int(binary.BigEndian.Uint16(b[regionsOffset:]))
+	regionCount := int(binary.BigEndian.Uint16(b[regionsOffset+2:]))
+	if regionsOffset+4+6*axisCount*regionCount > len(b) {
+		return nil, errStore
+	}
+	for i := 0; i < regionCount; i++ {
+		var region [3][]float64
+		for k := range region {
+			region[k] = make([]float64, axisCount)
+		}
+		for a := 0; a < axisCount; a++ {
+			rec := b[regionsOffset+4+6*(i*axisCount+a):]
+			region[0][a] = f2dot14(binary.BigEndian.Uint16(rec))
+			region[1][a] = f2dot14(binary.BigEndian.Uint16(rec[2:]))
+			region[2][a] = f2dot14(binary.BigEndian.Uint16(rec[4:]))
+		}
+		s.regions = append(s.regions, region)
+	}
+
+	dataCount := int(binary.BigEndian.Uint16(b[6:]))
+	if 8+4*dataCount > len(b) {
+		return nil, errStore
+	}
+	for i := 0; i < dataCount; i++ {
+		r := &woffReader{b: b, pos: int(binary.BigEndian.Uint32(b[8+4*i:]))}
+		itemCount, err1 := r.u16()
+		wordCount, err2 := r.u16()
+		regionIndexCount, err3 := r.u16()
+		if errors.Join(err1, err2, err3) != nil {
+			return nil, errStore
+		}
+		longWords := wordCount&0x8000 != 0
+		words := int(wordCount & 0x7fff)
+
+		var d itemVariationData
+		for j := 0; j < int(regionIndexCount); j++ {
+			idx, err := r.u16()
+			if err != nil || int(idx) >= regionCount {
+				return nil, errStore
+			}
+			d.regionIndexes = append(d.regionIndexes, int(idx))
+		}
+		for j := 0; j < int(itemCount); j++ {
+			row := make([]float64, regionIndexCount)
+			for k := range row {
+				var v float64
+				var err error
+				switch {
+				case k < words && longWords:
+					var u uint32
+					u, err = r.u32()
+					v = float64(int32(u))
+				case k < words || longWords:
+					var u uint16
+					u, err = r.u16()
+					v = float64(int16(u))
+				default:
+					var u uint8
+					u, err = r.u8()
+					v = float64(int8(u))
+				}
+				if err != nil {
+					return nil, errStore
+				}
+				row[k] = v
+			}
+			d.deltas = append(d.deltas, row)
+		}
+		s.data = append(s.data, d)
+	}
+	return s, nil
+}
+
+// delta returns the interpolated delta of item (outer, inner) at coords.
+func (s *itemVariationStore) delta(outer, inner int, coords []float64) float64 {
+	if outer >= len(s.data) || inner >= len(s.data[outer].deltas) {
+		return 0
+	}
+	d := s.data[outer]
+	sum := 0.0
+	for k, ri := range d.regionIndexes {
+		region := s.regions[ri]
+		sum += d.deltas[inner][k] * regionScalar(coords, region[0], region[1], region[2])
+	}
+	return sum
+}
+
+// hvarTable maps glyphs to advance width deltas.
+type hvarTable struct {
+	store      *itemVariationStore
+	advanceMap []byte // DeltaSetIndexMap, nil for the implicit glyph-id mapping
+}
+
+func parseHvar(b []byte) (*hvarTable, error) {
+	if len(b) < 20 {
+		return nil, errors.New("invalid HVAR table")
+	}
+	storeOffset := int(binary.BigEndian.Uint32(b[4:]))
+	mapOffset := int(binary.BigEndian.Uint32(b[8:]))
+	if storeOffset >= len(b) || mapOffset >= len(b) {
+		return nil, errors.New("invalid HVAR table")
+	}
+	store, err := parseItemVariationStore(b[storeOffset:])
+	if err != nil {
+		return nil, err
+	}
+	h := &hvarTable{store: store}
+	if mapOffset != 0 {
+		h.advanceMap = b[mapOffset:]
+	}
+	return h, nil
+}
+
+// advanceDelta returns the advance width delta of glyph g in font units.
+func (h *hvarTable) advanceDelta(g int, coords []float64) float64 {
+	outer, inner := 0, g
+	if m := h.advanceMap; len(m) >= 4 {
+		format, entryFormat := m[0], m[1]
+		count, pos := int(binary.BigEndian.Uint16(m[2:])), 4
+		if format == 1 && len(m) >= 6 {
+			count, pos = int(binary.BigEndian.Uint32(m[2:])), 6
+		}
+		if count == 0 {
+			return 0
+		}
+		g = min(g, count-1)
+		size := int(entryFormat>>4&3) + 1
+		innerBits := uint(entryFormat&0x0f) + 1
+		if pos+size*(g+1) > len(m) {
+			return 0
+		}
+		entry := 0
+		for _, b := range m[pos+size*g : pos+size*(g+1)] {
+			entry = entry<<8 | int(b)
+		}
+		outer, inner = entry>>innerBits, entry&(1<<innerBits-1)
+	}
+	return h.store.delta(outer, inner, coords)
+}
+
+// variation is a resolved variable font instance.
+type variation struct {
+	name        string
+	weight      float64   // wght axis value (0 without a wght axis)
+	bold        bool      // wght >= 700
+	italic      bool      // ital >= 0.5, or any slant
+	coords      []float64 // Normalized
+	glyf        *glyfTable
+	gvar        *gvarTable
+	hvar        *hvarTable
+	hmtx        []byte
+	numHMetrics int
+}
+
+// newVariation resolves a named instance and/or explicit axis values against
+// the font's fvar table.
+func newVariation(tables sfntTables, f *opentype.Font, namedInstance string, axes []AxisValue) (*variation, error) {
+	fvarAxes, instances, err := parseFvar(tables[tagOf("fvar")])
+	if err != nil {
+		return nil, err
+	}
+	if tables[tagOf("CFF2")] != nil {
+		return nil, errors.New("CFF2 variable fonts are not supported")
+	}
+
+	user := make([]float64, len(fvarAxes))
+	for i, a := range fvarAxes {
+		user[i] = a.def
+	}
+
+	if namedInstance != "" {
+		var buf sfnt.Buffer
+		var known []string
+		found := false
+		for _, inst := range instances {
+			name, err := f.Name(&buf, sfnt.NameID(inst.nameID))
+			if err != nil {
+				continue
+			}
+			known = append(known, name)
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(namedInstance)) {
+				copy(user, inst.coords)
+				found = true
+				break
+			}
+		}
+		if !found {
+			sort.Strings(known)
+			return nil, withKind(ErrInvalidOptions, fmt.Errorf("named instance %q not found (available: %s)", namedInstance, strings.Join(known, ", ")))
+		}
+	}
+
+	for _, av := range axes {
+		if err := validateAxisTag(av.Tag); err != nil {
+			return nil, withKind(ErrInvalidOptions, err)
+		}
+		idx := -1
+		for i, a := range fvarAxes {
+			if a.tag == av.Tag {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			var tags []string
+			for _, a := range fvarAxes {
+				tags = append(tags, a.tag)
+			}
+			return nil, withKind(ErrInvalidOptions, fmt.Errorf("unknown axis %q (available: %s)", av.Tag, strings.Join(tags, ", ")))
+		}
+		user[idx] = av.Value
+	}
+
+	v := &variation{
+		name:   VariationName(namedInstance, axes),
+		weight: axisValue(fvarAxes, user, "wght"),
+		bold:   axisValue(fvarAxes, user, "wght") >= 700,
+		italic: axisValue(fvarAxes, user, "ital") >= 0.5 || axisValue(fvarAxes, user, "slnt") != 0,
+		coords: normalize(fvarAxes, user, tables[tagOf("avar")]),
+		hmtx:   tables[tagOf("hmtx")],
+	}
+	if v.glyf, err = newGlyfTable(tables); err != nil {
+		return nil, err
+	}
+	if b := tables[tagOf("gvar")]; b != nil {
+		if v.gvar, err = parseGvar(b); err != nil {
+			return nil, err
+		}
+	}
+	if b := tables[tagOf("HVAR")]; b != nil {
+		if v.hvar, err = parseHvar(b); err != nil {
+			return nil, err
+		}
+	}
+	if hhea := tables[tagOf("hhea")]; len(hhea) >= 36 {
+		v.numHMetrics = int(binary.BigEndian.Uint16(hhea[34:]))
+	}
+	if v.numHMetrics == 0 || len(v.hmtx) < 4*v.numHMetrics {
+		return nil, errors.New("invalid hmtx table")
+	}
+	return v, nil
+}
+
+// axisValue returns the user-space value of the axis with the given tag (0 if absent).
+func axisValue(axes []fvarAxis, user []float64, tag string) float64 {
+	for i, a := range axes {
+		if a.tag == tag {
+			return user[i]
+		}
+	}
+	return 0
+}
+
+// horizontalMetrics returns the default advance and left side bearing of g.
+func (v *variation) horizontalMetrics(g int) (advance, lsb float64) {
+	i := min(g, v.numHMetrics-1)
+	advance = float64(binary.BigEndian.Uint16(v.hmtx[4*i:]))
+	if g < v.numHMetrics {
+		lsb = float64(int16(binary.BigEndian.Uint16(v.hmtx[4*g+2:])))
+	} else if p := 4*v.numHMetrics + 2*(g-v.numHMetrics); p+2 <= len(v.hmtx) {
+		lsb = float64(int16(binary.BigEndian.Uint16(v.hmtx[p:])))
+	}
+	return advance, lsb
+}
+
+// outline returns the varied contours of glyph g in font units, plus its
+// advance width.
+func (v *variation) outline(g int, depth int) (pts []vpoint, endPts []int, advance float64, err error) {
+	if depth > 8 {
+		return nil, nil, 0, errInvalidGlyf
+	}
+	data, err := v.glyf.data(g)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	glyph, err := parseGlyph(data)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Points (or component offsets) followed by the four phantom points.
+	adv, lsb := v.horizontalMetrics(g)
+	var base []vpoint
+	for _, p := range glyph.points {
+		base = append(base, vpoint{x: float64(p.x), y: float64(p.y), onCurve: p.onCurve})
+	}
+	for _, c := range glyph.components {
+		base = append(base, vpoint{x: float64(c.dx), y: float64(c.dy)})
+	}
+	left := float64(glyph.bbox[0]) - lsb
+	base = append(base, vpoint{x: left}, vpoint{x: left + adv}, vpoint{}, vpoint{})
+
+	if v.gvar != nil {
+		var contours []int
+		if glyph.components == nil {
+			contours = glyph.endPts
+		}
+		if err := v.gvar.applyDeltas(g, v.coords, base, contours); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	n := len(base) - 4
+	advance = base[n+1].x - base[n].x
+	if v.hvar != nil {
+		advance = adv + v.hvar.advanceDelta(g, v.coords)
+	}
+
+	if glyph.components == nil {
+		return base[:n], glyph.endPts, advance, nil
+	}
+
+	for i, c := range glyph.components {
+		cpts, cends, _, err := v.outline(c.glyph, depth+1)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		m := c.matrix
+		for j := range cpts {
+			x, y := cpts[j].x, cpts[j].y
+			cpts[j].x, cpts[j].y = m[0]*x+m[2]*y, m[1]*x+m[3]*y
+		}
+
+		var dx, dy float64
+		if c.flags&compArgsAreXY != 0 {
+			dx, dy = base[i].x, base[i].y
+			if c.flags&compScaledOffset != 0 {
+				dx, dy = m[0]*dx+m[2]*dy, m[1]*dx+m[3]*dy
+			}
+		} else if c.dx < len(pts) && c.dy < len(cpts) {
+			// Point matching: align the child's point with the parent's.
+			dx, dy = pts[c.dx].x-cpts[c.dy].x, pts[c.dx].y-cpts[c.dy].y
+		}
+
+		offset := len(pts)
+		for _, p := range cpts {
+			pts = append(pts, vpoint{x: p.x + dx, y: p.y + dy, onCurve: p.onCurve})
+		}
+		for _, e := range cends {
+			endPts = append(endPts, e+offset)
+		}
+	}
+	return pts, endPts, advance, nil
+}
+
+// segments converts contours in font units to sfnt segments in pixels
+// (y pointing down), as sfnt.Font.LoadGlyph would.
+func segments(pts []vpoint, endPts []int, scale float64) sfnt.Segments {
+	toFixed := func(p vpoint) fixed.Point26_6 {
+		return fixed.Point26_6{
+			X: fixed.Int26_6(math.Round(p.x * scale * 64)),
+			Y: fixed.Int26_6(math.Round(-p.y * scale * 64)),
+		}
+	}
+	mid := func(a, b vpoint) vpoint { return vpoint{x: (a.x + b.x) / 2, y: (a.y + b.y) / 2, onCurve: true} }
+
+	var segs sfnt.Segments
+	start := 0
+	for _, end := range endPts {
+		contour := pts[start : end+1]
+		start = end + 1
+		if len(contour) == 0 {
+			continue
+		}
+
+		// Start from an on-curve point (or the midpoint of two off-curve ones).
+		first := -1
+		for i, p := range contour {
+			if p.onCurve {
+				first = i
+				break
+			}
+		}
+		var startPt vpoint
+		if first < 0 {
+			startPt, first = mid(contour[0], contour[len(contour)-1]), 0
+		} else {
+			startPt = contour[first]
+			first++
+		}
+		segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpMoveTo, Args: [3]fixed.Point26_6{toFixed(startPt)}})
+
+		var ctrl *vpoint
+		for k := 0; k < len(contour); k++ {
+			p := contour[(first+k)%len(contour)]
+			if p.onCurve {
+				if ctrl == nil {
+					segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpLineTo, Args: [3]fixed.Point26_6{toFixed(p)}})
+				} else {
+					segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpQuadTo, Args: [3]fixed.Point26_6{toFixed(*ctrl), toFixed(p)}})
+					ctrl = nil
+				}
+				continue
+			}
+			if ctrl != nil {
+				m := mid(*ctrl, p)
+				segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpQuadTo, Args: [3]fixed.Point26_6{toFixed(*ctrl), toFixed(m)}})
+			}
+			c := p
+			ctrl = &c
+		}
+		// Close the contour back at the start point.
+		if ctrl != nil {
+			segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpQuadTo, Args: [3]fixed.Point26_6{toFixed(*ctrl), toFixed(startPt)}})
+		} else {
+			segs = append(segs, sfnt.Segment{Op: sfnt.SegmentOpLineTo, Args: [3]fixed.Point26_6{toFixed(startPt)}})
+		}
+	}
+	return segs
+}
+
+// variableFace implements font.Face for a variable font instance, using the
+// same rasterization as opentype.Face but with varied outlines and advances.
+// Hinting only affects advance rounding (as in sfnt).
+type variableFace struct {
+	f       *opentype.Font
+	v       *variation
+	ppem    fixed.Int26_6
+	scale   float64 // Pixels per font unit
+	hinting font.Hinting
+	metrics font.Metrics
+
+	buf  sfnt.Buffer
+	rast vector.Rasterizer
+	mask image.Alpha
+}
+
+// newVariableFace creates a face for the given instance at size points and dpi.
+func newVariableFace(f *opentype.Font, v *variation, size, dpi float64, hinting font.Hinting) (*variableFace, error) {
+	face := &variableFace{
+		f:       f,
+		v:       v,
+		ppem:    fixed.Int26_6(0.5 + (size * dpi * 64 / 72)),
+		hinting: hinting,
+	}
+	face.scale = float64(face.ppem) / 64 / float64(f.UnitsPerEm())
+	m, err := f.Metrics(&face.buf, face.ppem, hinting)
+	if err != nil {
+		return nil, err
+	}
+	face.metrics = m
+	return face, nil
+}
+
+func (f *variableFace) Close() error { return nil }
+
+func (f *variableFace) Metrics() font.Metrics { return f.metrics }
+
+func (f *variableFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	x0, _ := f.f.GlyphIndex(&f.buf, r0)
+	x1, _ := f.f.GlyphIndex(&f.buf, r1)
+	k, err := f.f.Kern(&f.buf, x0, x1, f.ppem, f.hinting)
+	if err != nil {
+		return 0
+	}
+	return k
+}
+
+// glyph returns the varied segments and advance of r.
+func (f *variableFace) glyph(r rune) (sfnt.Segments, fixed.Int26_6, bool) {
+	x, err := f.f.GlyphIndex(&f.buf, r)
+	if err != nil || x == 0 {
+		return nil, 0, false
+	}
+	pts, endPts, adv, err := f.v.outline(int(x), 0)
+	if err != nil {
+		return nil, 0, false
+	}
+	advance := fixed.Int26_6(math.Round(adv * f.scale * 64))
+	if f.hinting == font.HintingFull {
+		advance = (advance + 32) &^ 63
+	}
+	return segments(pts, endPts, f.scale), advance, true
+}
+
+func (f *variableFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	segs, advance, ok := f.glyph(r)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	dr, ok = rasterizeOutline(&f.rast, &f.mask, segs, dot)
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	return dr, &f.mask, image.Point{}, advance, true
+}
+
+func (f *variableFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	segs, advance, ok := f.glyph(r)
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return segs.Bounds(), advance, true
+}
+
+func (f *variableFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	_, advance, ok = f.glyph(r)
+	return advance, ok
+}
+
+// rasterizeOutline draws segs with the pen at dot into mask (reused between
+// calls), as opentype.Face does, and returns the destination rectangle.
+func rasterizeOutline(rast *vector.Rasterizer, mask *image.Alpha, segs sfnt.Segments, dot fixed.Point26_6) (dr image.Rectangle, ok bool) {
+	dBounds := segs.Bounds().Add(dot)
+	dr.Min.X = dBounds.Min.X.Floor()
+	dr.Min.Y = dBounds.Min.Y.Floor()
+	dr.Max.X = dBounds.Max.X.Ceil()
+	dr.Max.Y = dBounds.Max.Y.Ceil()
+	width, height := dr.Dx(), dr.Dy()
+	if width < 0 || height < 0 {
+		return image.Rectangle{}, false
+	}
+	biasX := dot.X - fixed.Int26_6(dr.Min.X<<6)
+	biasY := dot.Y - fixed.Int26_6(dr.Min.Y<<6)
+
+	if cap(mask.Pix) < width*height {
+		mask.Pix = make([]uint8, 2*width*height)
+	}
+	mask.Pix = mask.Pix[:width*height]
+	mask.Stride = width
+	mask.Rect = image.Rect(0, 0, width, height)
+
+	rast.Reset(width, height)
+	rast.DrawOp = draw.Src
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float32(p.X+biasX) / 64, float32(p.Y+biasY) / 64
+	}
+	for _, seg := range segs {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			rast.MoveTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			rast.LineTo(pt(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := pt(seg.Args[0])
+			x2, y2 := pt(seg.Args[1])
+			rast.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo: // CFF outlines
+			x1, y1 := pt(seg.Args[0])
+			x2, y2 := pt(seg.Args[1])
+			x3, y3 := pt(seg.Args[2])
+			rast.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	rast.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	return dr, true
+}
@@ -0,0 +1,207 @@
+package converter
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// buildVariableFont turns Go Regular into a one-axis (wght 100..400..900)
+// variable font, where glyph g moves right by 100 units at wght=900 and its
+// advance grows by the same amount. The single named instance uses name ID 2
+// ("Regular") and sits at wght=900.
+func buildVariableFont(t *testing.T, g int) []byte {
+	t.Helper()
+	flavor, tables := splitSFNT(t, goregular.TTF)
+	byTag := sfntTables{}
+	for _, tbl := range tables {
+		byTag[tbl.tag] = tbl.data
+	}
+
+	fvar := make([]byte, 16+20+8)
+	binary.BigEndian.PutUint16(fvar, 1)
+	binary.BigEndian.PutUint16(fvar[4:], 16)
+	binary.BigEndian.PutUint16(fvar[6:], 2)
+	binary.BigEndian.PutUint16(fvar[8:], 1)
+	binary.BigEndian.PutUint16(fvar[10:], 20)
+	binary.BigEndian.PutUint16(fvar[12:], 1)
+	binary.BigEndian.PutUint16(fvar[14:], 8)
+	copy(fvar[16:], "wght")
+	binary.BigEndian.PutUint32(fvar[20:], 100<<16)
+	binary.BigEndian.PutUint32(fvar[24:], 400<<16)
+	binary.BigEndian.PutUint32(fvar[28:], 900<<16)
+	binary.BigEndian.PutUint16(fvar[34:], 2)
+	binary.BigEndian.PutUint16(fvar[36:], 2)
+	binary.BigEndian.PutUint32(fvar[40:], 900<<16)
+
+	glyf, err := newGlyfTable(byTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := glyf.data(g)
+	glyph, err := parseGlyph(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numPoints := len(glyph.points) + 4
+
+	// One tuple with an embedded peak at wght=+1 and private "all points".
+	tuple := []byte{0}
+	for _, axis := range []int{100, 0} {
+		for done := 0; done < numPoints; {
+			run := min(64, numPoints-done)
+			tuple = append(tuple, 0x40|byte(run-1))
+			for i := 0; i < run; i++ {
+				d := axis
+				if done+i == numPoints-4 { // Left phantom point stays put
+					d = 0
+				}
+				tuple = binary.BigEndian.AppendUint16(tuple, uint16(int16(d)))
+			}
+			done += run
+		}
+	}
+	varData := []byte{0, 1, 0, 10}
+	varData = binary.BigEndian.AppendUint16(varData, uint16(len(tuple)))
+	varData = binary.BigEndian.AppendUint16(varData, 0x8000|0x2000)
+	varData = binary.BigEndian.AppendUint16(varData, 0x4000) // Peak: +1.0
+	varData = append(varData, tuple...)
+
+	glyphCount := glyf.numGlyphs
+	gvar := make([]byte, 20+4*(glyphCount+1))
+	binary.BigEndian.PutUint16(gvar, 1)
+	binary.BigEndian.PutUint16(gvar[4:], 1)
+	binary.BigEndian.PutUint32(gvar[8:], uint32(len(gvar)))
+	binary.BigEndian.PutUint16(gvar[12:], uint16(glyphCount))
+	binary.BigEndian.PutUint16(gvar[14:], 1)
+	binary.BigEndian.PutUint32(gvar[16:], uint32(len(gvar)))
+	for i := g + 1; i <= glyphCount; i++ {
+		binary.BigEndian.PutUint32(gvar[20+4*i:], uint32(len(varData)))
+	}
+	gvar = append(gvar, varData...)
+
+	tables = append(tables, sfntTable{tag: tagOf("fvar"), data: fvar}, sfntTable{tag: tagOf("gvar"), data: gvar})
+	return assembleSFNT(flavor, tables)
+}
+
+func TestVariableFace(t *testing.T) {
+	base, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := base.GlyphIndex(nil, 'l')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := buildVariableFont(t, int(idx))
+	f, err := opentype.Parse(data)
+	if err != nil {
+		t.Fatalf("parsing variable font: %v", err)
+	}
+	tables, err := readTables(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newFace := func(named string, axes []AxisValue) *variableFace {
+		t.Helper()
+		v, err := newVariation(tables, f, named, axes)
+		if err != nil {
+			t.Fatalf("newVariation(%q, %v) failed: %v", named, axes, err)
+		}
+		face, err := newVariableFace(f, v, 2048, 72, font.HintingNone) // 1px per font unit
+		if err != nil {
+			t.Fatal(err)
+		}
+		return face
+	}
+
+	ref, _ := opentype.NewFace(base, &opentype.FaceOptions{Size: 2048, DPI: 72})
+
+	// The default instance must match the static font for every character.
+	def := newFace("", []AxisValue{{Tag: "wght", Value: 400}})
+	for _, r := range "AaBbgjlpQ&@é" {
+		wantB, wantA, _ := ref.GlyphBounds(r)
+		gotB, gotA, ok := def.GlyphBounds(r)
+		if !ok || gotA != wantA || !closeRect(gotB, wantB) {
+			t.Errorf("default %q: bounds %v adv %v, want %v adv %v", r, gotB, gotA, wantB, wantA)
+		}
+	}
+
+	// At wght=900 (and via the named instance) 'l' moves and widens by 100 units.
+	wantB, wantA, _ := ref.GlyphBounds('l')
+	shift := fixed.I(100)
+	for _, face := range []*variableFace{
+		newFace("", []AxisValue{{Tag: "wght", Value: 900}}),
+		newFace("regular", nil),
+	} {
+		gotB, gotA, ok := face.GlyphBounds('l')
+		if !ok || gotA != wantA+shift || !closeRect(gotB, wantB.Add(fixed.Point26_6{X: shift})) {
+			t.Errorf("wght=900 'l': bounds %v adv %v, want %v adv %v", gotB, gotA, wantB.Add(fixed.Point26_6{X: shift}), wantA+shift)
+		}
+	}
+
+	// Halfway (wght=650) gives half the delta.
+	gotB, _, _ := newFace("", []AxisValue{{Tag: "wght", Value: 650}}).GlyphBounds('l')
+	if want := wantB.Add(fixed.Point26_6{X: fixed.I(50)}); !closeRect(gotB, want) {
+		t.Errorf("wght=650 'l': bounds %v, want %v", gotB, want)
+	}
+
+	if _, err := newVariation(tables, f, "", []AxisValue{{Tag: "wdth", Value: 85}}); err == nil {
+		t.Error("expected an error for an unknown axis")
+	}
+	if _, err := newVariation(tables, f, "Black", nil); err == nil {
+		t.Error("expected an error for an unknown named instance")
+	}
+}
+
+// closeRect allows one 26.6 unit of rounding difference per edge.
+func closeRect(a, b fixed.Rectangle26_6) bool {
+	near := func(x, y fixed.Int26_6) bool { return x-y <= 1 && y-x <= 1 }
+	return near(a.Min.X, b.Min.X) && near(a.Min.Y, b.Min.Y) && near(a.Max.X, b.Max.X) && near(a.Max.Y, b.Max.Y)
+}
+
+func TestInterpolateUntouched(t *testing.T) {
+	// A square contour where only the left and right edges' first points move.
+	orig := []vpoint{{x: 0, y: 0}, {x: 0, y: 100}, {x: 100, y: 100}, {x: 100, y: 0}, {x: 50, y: 0}}
+	deltas := make([]vpoint, len(orig))
+	touched := []bool{true, false, true, false, false}
+	deltas[0] = vpoint{x: -10}
+	deltas[2] = vpoint{x: 30}
+
+	interpolateUntouched(orig, deltas, touched, []int{4})
+
+	want := []float64{-10, -10, 30, 30, 10}
+	for i, w := range want {
+		if deltas[i].x != w {
+			t.Errorf("point %d: dx = %v, want %v", i, deltas[i].x, w)
+		}
+	}
+}
+
+func TestParseAxes(t *testing.T) {
+	axes, err := ParseAxes("wght=700, wdth=85.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(axes) != 2 || axes[0] != (AxisValue{"wght", 700}) || axes[1] != (AxisValue{"wdth", 85.5}) {
+		t.Errorf("ParseAxes() = %v", axes)
+	}
+	if got := VariationName("Bold", axes); got != "Bold wght700 wdth85.5" {
+		t.Errorf("VariationName() = %q", got)
+	}
+	if _, err := ParseAxes("wght"); err == nil {
+		t.Error("expected an error for a missing value")
+	}
+	for _, bad := range []string{"wg=700", "weight=700", "wdt=85"} {
+		if _, err := ParseAxes(bad); err == nil || !strings.Contains(err.Error(), "axis tag") {
+			t.Errorf("ParseAxes(%q): err = %v, want an axis tag error", bad, err)
+		}
+	}
+}
@@ -0,0 +1,998 @@
+package converter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Web fonts (WOFF 1.0 and WOFF 2.0) are decoded back into plain sfnt bytes
+// in memory, so the rest of the pipeline only ever sees TTF/OTF/TTC data.
+//
+// Specs: https://www.w3.org/TR/WOFF/ and https://www.w3.org/TR/WOFF2/
+
+const (
+	woffSignature  = 0x774f4646 // "wOFF"
+	woff2Signature = 0x774f4632 // "wOF2"
+	ttcfTag        = 0x74746366 // "ttcf"
+
+	// Upper bound on decoded font size, to reject hostile headers early.
+	maxDecodedFontSize = 1 << 30
+)
+
+var errInvalidWOFF = errors.New("invalid WOFF data")
+
+// isWOFF reports whether data is a WOFF 1.0 or WOFF 2.0 web font.
+func isWOFF(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	sig := binary.BigEndian.Uint32(data)
+	return sig == woffSignature || sig == woff2Signature
+}
+
+// decodeWOFF converts WOFF 1.0 or WOFF 2.0 data into sfnt bytes.
+func decodeWOFF(data []byte) ([]byte, error) {
+	switch binary.BigEndian.Uint32(data) {
+	case woffSignature:
+		return decodeWOFF1(data)
+	case woff2Signature:
+		return decodeWOFF2(data)
+	}
+	return nil, errInvalidWOFF
+}
+
+// sfntTable is a single decoded table, ready to be written to an sfnt file.
+type sfntTable struct {
+	tag  uint32
+	data []byte
+}
+
+// decodeWOFF1 decodes a WOFF 1.0 file: every table is stored either as-is or
+// zlib-compressed.
+func decodeWOFF1(data []byte) ([]byte, error) {
+	const headerSize, entrySize = 44, 20
+	if len(data) < headerSize {
+		return nil, errInvalidWOFF
+	}
+	flavor := binary.BigEndian.Uint32(data[4:])
+	numTables := int(binary.BigEndian.Uint16(data[12:]))
+	if flavor == ttcfTag || len(data) < headerSize+entrySize*numTables {
+		return nil, errInvalidWOFF
+	}
+
+	tables := make([]sfntTable, numTables)
+	total := 0
+	for i := range tables {
+		e := data[headerSize+entrySize*i:]
+		tag := binary.BigEndian.Uint32(e)
+		offset := int(binary.BigEndian.Uint32(e[4:]))
+		compLength := int(binary.BigEndian.Uint32(e[8:]))
+		origLength := int(binary.BigEndian.Uint32(e[12:]))
+		if offset < 0 || compLength < 0 || offset+compLength > len(data) || compLength > origLength {
+			return nil, errInvalidWOFF
+		}
+		total += origLength
+		if total > maxDecodedFontSize {
+			return nil, errInvalidWOFF
+		}
+
+		raw := data[offset : offset+compLength]
+		if compLength == origLength {
+			tables[i] = sfntTable{tag: tag, data: raw}
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing WOFF table: %w", err)
+		}
+		out := make([]byte, origLength)
+		if _, err := io.ReadFull(zr, out); err != nil {
+			return nil, fmt.Errorf("decompressing WOFF table: %w", err)
+		}
+		tables[i] = sfntTable{tag: tag, data: out}
+	}
+
+	return assembleSFNT(flavor, tables), nil
+}
+
+// woff2KnownTags is the WOFF2 table tag dictionary, indexed by the low 6 bits
+// of a table directory entry's flags byte.
+var woff2KnownTags = [...]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post",
+	"cvt ", "fpgm", "glyf", "loca", "prep", "CFF ", "VORG", "EBDT",
+	"EBLC", "gasp", "hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea",
+	"vmtx", "BASE", "GDEF", "GPOS", "GSUB", "EBSC", "JSTF", "MATH",
+	"CBDT", "CBLC", "COLR", "CPAL", "SVG ", "sbix", "acnt", "avar",
+	"bdat", "bloc", "bsln", "cvar", "fdsc", "feat", "fmtx", "fvar",
+	"gvar", "hsty", "just", "lcar", "mort", "morx", "opbd", "prop",
+	"trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill",
+}
+
+func tagOf(s string) uint32 { return binary.BigEndian.Uint32([]byte(s)) }
+
+var (
+	tagGlyf = tagOf("glyf")
+	tagLoca = tagOf("loca")
+	tagHmtx = tagOf("hmtx")
+	tagHhea = tagOf("hhea")
+	tagHead = tagOf("head")
+	tagMaxp = tagOf("maxp")
+)
+
+// woff2Entry is a WOFF2 table directory entry.
+type woff2Entry struct {
+	tag         uint32
+	transformed bool
+	origLength  int
+	length      int // Length of the (possibly transformed) data in the stream
+	data        []byte
+}
+
+// decodeWOFF2 decodes a WOFF 2.0 file: a single Brotli stream holding every
+// table, where glyf/loca and hmtx may be stored in a transformed layout.
+func decodeWOFF2(data []byte) ([]byte, error) {
+	const headerSize = 48
+	if len(data) < headerSize {
+		return nil, errInvalidWOFF
+	}
+	flavor := binary.BigEndian.Uint32(data[4:])
+	numTables := int(binary.BigEndian.Uint16(data[12:]))
+	totalCompressedSize := int(binary.BigEndian.Uint32(data[20:]))
+	if numTables == 0 {
+		return nil, errInvalidWOFF
+	}
+
+	r := &woffReader{b: data, pos: headerSize}
+
+	// 1. Table Directory
+	entries := make([]woff2Entry, numTables)
+	streamSize := 0
+	for i := range entries {
+		flags, err := r.u8()
+		if err != nil {
+			return nil, err
+		}
+		e := &entries[i]
+		if idx := int(flags & 0x3f); idx == 0x3f {
+			if e.tag, err = r.u32(); err != nil {
+				return nil, err
+			}
+		} else if idx < len(woff2KnownTags) {
+			e.tag = tagOf(woff2KnownTags[idx])
+		} else {
+			return nil, errInvalidWOFF
+		}
+
+		// For glyf/loca, version 0 is the transform and 3 is the null transform.
+		// For every other table it is the other way around.
+		version := flags >> 6
+		if e.tag == tagGlyf || e.tag == tagLoca {
+			e.transformed = version == 0
+		} else {
+			e.transformed = version != 0
+		}
+
+		if e.origLength, err = r.base128(); err != nil {
+			return nil, err
+		}
+		e.length = e.origLength
+		if e.transformed {
+			if e.length, err = r.base128(); err != nil {
+				return nil, err
+			}
+		}
+		streamSize += e.length
+		if streamSize > maxDecodedFontSize {
+			return nil, errInvalidWOFF
+		}
+	}
+
+	// 2. Collection Directory
+	var fonts []woff2Font
+	if flavor == ttcfTag {
+		var err error
+		if fonts, err = r.collectionDirectory(numTables); err != nil {
+			return nil, err
+		}
+	} else {
+		font := woff2Font{flavor: flavor}
+		for i := range entries {
+			font.tables = append(font.tables, i)
+		}
+		fonts = []woff2Font{font}
+	}
+
+	// 3. Compressed Data
+	if r.pos+totalCompressedSize > len(data) {
+		return nil, errInvalidWOFF
+	}
+	stream := make([]byte, streamSize)
+	br := brotli.NewReader(bytes.NewReader(data[r.pos : r.pos+totalCompressedSize]))
+	if _, err := io.ReadFull(br, stream); err != nil {
+		return nil, fmt.Errorf("decompressing WOFF2 data: %w", err)
+	}
+	for i := range entries {
+		entries[i].data, stream = stream[:entries[i].length], stream[entries[i].length:]
+	}
+
+	// 4. Reverse the transforms, font by font (tables may be shared in collections)
+	tables := make([]sfntTable, numTables)
+	done := make([]bool, numTables)
+	for _, font := range fonts {
+		if err := reconstructFont(entries, font.tables, tables, done); err != nil {
+			return nil, err
+		}
+	}
+	for i, e := range entries {
+		if !done[i] {
+			tables[i] = sfntTable{tag: e.tag, data: e.data}
+		}
+	}
+
+	if flavor != ttcfTag {
+		return assembleSFNT(flavor, tables), nil
+	}
+	return assembleCollection(fonts, tables), nil
+}
+
+// woff2Font lists the tables (as directory indices) making up one font.
+type woff2Font struct {
+	flavor uint32
+	tables []int
+}
+
+// reconstructFont reverses the glyf/loca and hmtx transforms of one font.
+// Decoded tables are stored in tables[i], and marked in done[i].
+func reconstructFont(entries []woff2Entry, indices []int, tables []sfntTable, done []bool) error {
+	find := func(tag uint32) int {
+		for _, i := range indices {
+			if entries[i].tag == tag {
+				return i
+			}
+		}
+		return -1
+	}
+
+	glyf, loca := find(tagGlyf), find(tagLoca)
+	if glyf >= 0 && entries[glyf].transformed {
+		if loca < 0 || !entries[loca].transformed {
+			return errInvalidWOFF
+		}
+		if !done[glyf] {
+			glyfData, locaData, err := reconstructGlyf(entries[glyf].data)
+			if err != nil {
+				return err
+			}
+			if len(locaData) != entries[loca].origLength {
+				return errInvalidWOFF
+			}
+			tables[glyf] = sfntTable{tag: tagGlyf, data: glyfData}
+			tables[loca] = sfntTable{tag: tagLoca, data: locaData}
+			done[glyf], done[loca] = true, true
+		}
+	} else if loca >= 0 && entries[loca].transformed {
+		return errInvalidWOFF
+	}
+
+	hmtx := find(tagHmtx)
+	if hmtx < 0 || !entries[hmtx].transformed || done[hmtx] {
+		return nil
+	}
+	hhea, maxp, head := find(tagHhea), find(tagMaxp), find(tagHead)
+	if glyf < 0 || loca < 0 || hhea < 0 || maxp < 0 || head < 0 {
+		return errInvalidWOFF
+	}
+	glyfData, locaData := entries[glyf].data, entries[loca].data
+	if done[glyf] {
+		glyfData, locaData = tables[glyf].data, tables[loca].data
+	}
+	xMins, err := glyphXMins(glyfData, locaData, entries[head].data, entries[maxp].data)
+	if err != nil {
+		return err
+	}
+	if len(entries[hhea].data) < 36 {
+		return errInvalidWOFF
+	}
+	numHMetrics := int(binary.BigEndian.Uint16(entries[hhea].data[34:]))
+	hmtxData, err := reconstructHmtx(entries[hmtx].data, numHMetrics, xMins)
+	if err != nil {
+		return err
+	}
+	tables[hmtx] = sfntTable{tag: tagHmtx, data: hmtxData}
+	done[hmtx] = true
+	return nil
+}
+
+// reconstructGlyf rebuilds the glyf and loca tables from the WOFF2
+// transformed glyf stream (WOFF2 spec, section 5.1).
+func reconstructGlyf(data []byte) (glyf, loca []byte, err error) {
+	const headerSize = 36
+	if len(data) < headerSize {
+		return nil, nil, errInvalidWOFF
+	}
+	optionFlags := binary.BigEndian.Uint16(data[2:])
+	numGlyphs := int(binary.BigEndian.Uint16(data[4:]))
+	indexFormat := binary.BigEndian.Uint16(data[6:])
+
+	// Split the seven sub-streams
+	var streams [7][]byte
+	pos := headerSize
+	for i := range streams {
+		n := int(binary.BigEndian.Uint32(data[8+4*i:]))
+		if n < 0 || pos+n > len(data) {
+			return nil, nil, errInvalidWOFF
+		}
+		streams[i] = data[pos : pos+n]
+		pos += n
+	}
+	nContour := &woffReader{b: streams[0]}
+	nPoints := &woffReader{b: streams[1]}
+	flagStream := &woffReader{b: streams[2]}
+	glyphStream := &woffReader{b: streams[3]}
+	composite := &woffReader{b: streams[4]}
+	instructions := &woffReader{b: streams[6]}
+
+	bitmapSize := 4 * ((numGlyphs + 31) / 32)
+	if len(streams[5]) < bitmapSize {
+		return nil, nil, errInvalidWOFF
+	}
+	bboxBitmap := streams[5][:bitmapSize]
+	bboxStream := &woffReader{b: streams[5][bitmapSize:]}
+
+	var overlapBitmap []byte
+	if optionFlags&1 != 0 {
+		n := (numGlyphs + 7) / 8
+		if pos+n > len(data) {
+			return nil, nil, errInvalidWOFF
+		}
+		overlapBitmap = data[pos : pos+n]
+	}
+
+	offsets := make([]int, numGlyphs+1)
+	for g := 0; g < numGlyphs; g++ {
+		offsets[g] = len(glyf)
+
+		n, err := nContour.u16()
+		if err != nil {
+			return nil, nil, err
+		}
+		contours := int(int16(n))
+		hasBBox := bboxBitmap[g>>3]&(0x80>>(g&7)) != 0
+
+		var glyph []byte
+		switch {
+		case contours == 0:
+			if hasBBox {
+				return nil, nil, errInvalidWOFF
+			}
+		case contours > 0:
+			overlap := overlapBitmap != nil && overlapBitmap[g>>3]&(0x80>>(g&7)) != 0
+			glyph, err = decodeSimpleGlyph(contours, nPoints, flagStream, glyphStream, instructions, hasBBox, bboxStream, overlap)
+		case contours == -1:
+			if !hasBBox {
+				return nil, nil, errInvalidWOFF
+			}
+			glyph, err = decodeCompositeGlyph(composite, glyphStream, instructions, bboxStream)
+		default:
+			return nil, nil, errInvalidWOFF
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		glyf = append(glyf, glyph...)
+		// Short loca offsets are stored halved, so keep every glyph 2-aligned;
+		// long offsets get the customary 4-byte alignment.
+		align := 4
+		if indexFormat == 0 {
+			align = 2
+		}
+		for len(glyf)%align != 0 {
+			glyf = append(glyf, 0)
+		}
+	}
+	offsets[numGlyphs] = len(glyf)
+
+	if indexFormat == 0 {
+		if len(glyf) > 0x1ffff {
+			return nil, nil, errInvalidWOFF
+		}
+		loca = make([]byte, 2*len(offsets))
+		for i, o := range offsets {
+			binary.BigEndian.PutUint16(loca[2*i:], uint16(o/2))
+		}
+	} else {
+		loca = make([]byte, 4*len(offsets))
+		for i, o := range offsets {
+			binary.BigEndian.PutUint32(loca[4*i:], uint32(o))
+		}
+	}
+	return glyf, loca, nil
+}
+
+// decodeSimpleGlyph rebuilds a simple glyph from the WOFF2 point streams and
+// encodes it in the standard glyf layout.
+func decodeSimpleGlyph(contours int, nPoints, flagStream, glyphStream, instructions *woffReader, hasBBox bool, bboxStream *woffReader, overlap bool) ([]byte, error) {
+	endPts := make([]int, contours)
+	total := 0
+	for i := range endPts {
+		n, err := nPoints.u255()
+		if err != nil {
+			return nil, err
+		}
+		total += n
+		if total > 0xffff {
+			return nil, errInvalidWOFF
+		}
+		endPts[i] = total - 1
+	}
+
+	pts := make([]glyfPoint, total)
+	x, y := 0, 0
+	for i := range pts {
+		flag, err := flagStream.u8()
+		if err != nil {
+			return nil, err
+		}
+		dx, dy, err := glyphStream.triplet(flag)
+		if err != nil {
+			return nil, err
+		}
+		x += dx
+		y += dy
+		pts[i] = glyfPoint{x: x, y: y, onCurve: flag&0x80 == 0}
+	}
+
+	instrLen, err := glyphStream.u255()
+	if err != nil {
+		return nil, err
+	}
+	instr, err := instructions.bytes(instrLen)
+	if err != nil {
+		return nil, err
+	}
+
+	var bbox [4]int16
+	if hasBBox {
+		if bbox, err = bboxStream.bbox(); err != nil {
+			return nil, err
+		}
+	} else {
+		bbox = pointsBBox(pts)
+	}
+
+	return encodeSimpleGlyph(bbox, endPts, instr, pts, overlap), nil
+}
+
+// decodeCompositeGlyph copies a composite glyph's component records from the
+// composite stream, plus its instructions if it has any.
+func decodeCompositeGlyph(composite, glyphStream, instructions *woffReader, bboxStream *woffReader) ([]byte, error) {
+	const (
+		argsAreWords     = 0x0001
+		haveScale        = 0x0008
+		moreComponents   = 0x0020
+		haveXYScale      = 0x0040
+		haveTwoByTwo     = 0x0080
+		haveInstructions = 0x0100
+	)
+
+	bbox, err := bboxStream.bbox()
+	if err != nil {
+		return nil, err
+	}
+
+	start := composite.pos
+	hasInstructions := false
+	for {
+		flags, err := composite.u16()
+		if err != nil {
+			return nil, err
+		}
+		n := 2 // glyphIndex
+		if flags&argsAreWords != 0 {
+			n += 4
+		} else {
+			n += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			n += 2
+		case flags&haveXYScale != 0:
+			n += 4
+		case flags&haveTwoByTwo != 0:
+			n += 8
+		}
+		if _, err := composite.bytes(n); err != nil {
+			return nil, err
+		}
+		if flags&haveInstructions != 0 {
+			hasInstructions = true
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	records := composite.b[start:composite.pos]
+
+	glyph := make([]byte, 10, 10+len(records))
+	binary.BigEndian.PutUint16(glyph, 0xffff) // numberOfContours = -1
+	for i, v := range bbox {
+		binary.BigEndian.PutUint16(glyph[2+2*i:], uint16(v))
+	}
+	glyph = append(glyph, records...)
+
+	if hasInstructions {
+		instrLen, err := glyphStream.u255()
+		if err != nil {
+			return nil, err
+		}
+		instr, err := instructions.bytes(instrLen)
+		if err != nil {
+			return nil, err
+		}
+		glyph = binary.BigEndian.AppendUint16(glyph, uint16(instrLen))
+		glyph = append(glyph, instr...)
+	}
+	return glyph, nil
+}
+
+// glyfPoint is a single outline point in font units.
+type glyfPoint struct {
+	x, y    int
+	onCurve bool
+}
+
+// pointsBBox computes the bounding box of a simple glyph's points.
+func pointsBBox(pts []glyfPoint) [4]int16 {
+	if len(pts) == 0 {
+		return [4]int16{}
+	}
+	xMin, yMin, xMax, yMax := pts[0].x, pts[0].y, pts[0].x, pts[0].y
+	for _, p := range pts[1:] {
+		xMin, xMax = min(xMin, p.x), max(xMax, p.x)
+		yMin, yMax = min(yMin, p.y), max(yMax, p.y)
+	}
+	return [4]int16{int16(xMin), int16(yMin), int16(xMax), int16(yMax)}
+}
+
+// encodeSimpleGlyph writes a simple glyph in the standard glyf layout, using
+// the short (one byte) coordinate form whenever the delta allows it.
+func encodeSimpleGlyph(bbox [4]int16, endPts []int, instr []byte, pts []glyfPoint, overlap bool) []byte {
+	const (
+		onCurve       = 0x01
+		xShort        = 0x02
+		yShort        = 0x04
+		xSameOrPos    = 0x10
+		ySameOrPos    = 0x20
+		overlapSimple = 0x40
+	)
+
+	out := make([]byte, 10, 12+2*len(endPts)+len(instr)+5*len(pts))
+	binary.BigEndian.PutUint16(out, uint16(len(endPts)))
+	for i, v := range bbox {
+		binary.BigEndian.PutUint16(out[2+2*i:], uint16(v))
+	}
+	for _, e := range endPts {
+		out = binary.BigEndian.AppendUint16(out, uint16(e))
+	}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(instr)))
+	out = append(out, instr...)
+
+	var xs, ys []byte
+	flags := make([]byte, len(pts))
+	px, py := 0, 0
+	for i, p := range pts {
+		var f byte
+		if p.onCurve {
+			f |= onCurve
+		}
+		if i == 0 && overlap {
+			f |= overlapSimple
+		}
+		dx, dy := p.x-px, p.y-py
+		px, py = p.x, p.y
+
+		switch {
+		case dx == 0:
+			f |= xSameOrPos
+		case dx >= -255 && dx <= 255:
+			f |= xShort
+			if dx > 0 {
+				f |= xSameOrPos
+			}
+			xs = append(xs, byte(abs(dx)))
+		default:
+			xs = binary.BigEndian.AppendUint16(xs, uint16(int16(dx)))
+		}
+		switch {
+		case dy == 0:
+			f |= ySameOrPos
+		case dy >= -255 && dy <= 255:
+			f |= yShort
+			if dy > 0 {
+				f |= ySameOrPos
+			}
+			ys = append(ys, byte(abs(dy)))
+		default:
+			ys = binary.BigEndian.AppendUint16(ys, uint16(int16(dy)))
+		}
+		flags[i] = f
+	}
+
+	out = append(out, flags...)
+	out = append(out, xs...)
+	return append(out, ys...)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// glyphXMins returns the xMin of every glyph (0 for empty glyphs), which is
+// what a transformed hmtx table omits in place of left side bearings.
+func glyphXMins(glyf, loca, head, maxp []byte) ([]int16, error) {
+	if len(head) < 54 || len(maxp) < 6 {
+		return nil, errInvalidWOFF
+	}
+	longLoca := binary.BigEndian.Uint16(head[50:]) != 0
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+
+	offset := func(i int) int {
+		if longLoca {
+			return int(binary.BigEndian.Uint32(loca[4*i:]))
+		}
+		return 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+	}
+	if (longLoca && len(loca) < 4*(numGlyphs+1)) || (!longLoca && len(loca) < 2*(numGlyphs+1)) {
+		return nil, errInvalidWOFF
+	}
+
+	xMins := make([]int16, numGlyphs)
+	for g := range xMins {
+		start, end := offset(g), offset(g+1)
+		if end < start || end > len(glyf) {
+			return nil, errInvalidWOFF
+		}
+		if end-start >= 10 {
+			xMins[g] = int16(binary.BigEndian.Uint16(glyf[start+2:]))
+		}
+	}
+	return xMins, nil
+}
+
+// reconstructHmtx rebuilds an hmtx table from its WOFF2 transformed form
+// (WOFF2 spec, section 5.4), restoring omitted side bearings from xMins.
+func reconstructHmtx(data []byte, numHMetrics int, xMins []int16) ([]byte, error) {
+	numGlyphs := len(xMins)
+	if numHMetrics < 1 || numHMetrics > numGlyphs || len(data) < 1 {
+		return nil, errInvalidWOFF
+	}
+	flags := data[0]
+	r := &woffReader{b: data, pos: 1}
+
+	out := make([]byte, 4*numHMetrics+2*(numGlyphs-numHMetrics))
+	for i := 0; i < numHMetrics; i++ {
+		aw, err := r.u16()
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(out[4*i:], aw)
+	}
+	for i := 0; i < numHMetrics; i++ {
+		lsb := uint16(xMins[i])
+		if flags&1 == 0 {
+			var err error
+			if lsb, err = r.u16(); err != nil {
+				return nil, err
+			}
+		}
+		binary.BigEndian.PutUint16(out[4*i+2:], lsb)
+	}
+	for i := numHMetrics; i < numGlyphs; i++ {
+		lsb := uint16(xMins[i])
+		if flags&2 == 0 {
+			var err error
+			if lsb, err = r.u16(); err != nil {
+				return nil, err
+			}
+		}
+		binary.BigEndian.PutUint16(out[4*numHMetrics+2*(i-numHMetrics):], lsb)
+	}
+	return out, nil
+}
+
+// assembleSFNT writes a single-font sfnt file from its tables.
+func assembleSFNT(flavor uint32, tables []sfntTable) []byte {
+	indices := make([]int, len(tables))
+	for i := range indices {
+		indices[i] = i
+	}
+	out := assembleCollection([]woff2Font{{flavor: flavor, tables: indices}}, tables)
+
+	// Fix up head.checkSumAdjustment now that the whole file is known.
+	for i := range indices {
+		rec := out[12+16*i:]
+		if binary.BigEndian.Uint32(rec) == tagHead && binary.BigEndian.Uint32(rec[12:]) >= 12 {
+			headOffset := int(binary.BigEndian.Uint32(rec[8:]))
+			binary.BigEndian.PutUint32(out[headOffset+8:], 0xb1b0afba-sfntChecksum(out))
+		}
+	}
+	return out
+}
+
+// assembleCollection writes the sfnt file for one or more fonts. With more
+// than one font (or a 'ttcf' flavor) it produces a TTC, writing shared
+// tables only once.
+func assembleCollection(fonts []woff2Font, tables []sfntTable) []byte {
+	collection := len(fonts) > 1 || (len(fonts) == 1 && fonts[0].flavor == ttcfTag)
+
+	// 1. Header(s) & Table Directories
+	size := 0
+	if collection {
+		size = 12 + 4*len(fonts)
+	}
+	dirOffsets := make([]int, len(fonts))
+	for i, f := range fonts {
+		dirOffsets[i] = size
+		size += 12 + 16*len(f.tables)
+	}
+
+	// 2. Table Data
+	tableOffsets := make([]int, len(tables))
+	for i, t := range tables {
+		size = (size + 3) &^ 3
+		tableOffsets[i] = size
+		size += len(t.data)
+	}
+	out := make([]byte, (size+3)&^3)
+	for i, t := range tables {
+		copy(out[tableOffsets[i]:], t.data)
+		if t.tag == tagHead && len(t.data) >= 12 {
+			// Zeroed for now; assembleSFNT fills it in for single fonts.
+			binary.BigEndian.PutUint32(out[tableOffsets[i]+8:], 0)
+		}
+	}
+
+	if collection {
+		binary.BigEndian.PutUint32(out, ttcfTag)
+		binary.BigEndian.PutUint32(out[4:], 0x00010000)
+		binary.BigEndian.PutUint32(out[8:], uint32(len(fonts)))
+		for i, o := range dirOffsets {
+			binary.BigEndian.PutUint32(out[12+4*i:], uint32(o))
+		}
+	}
+
+	for i, f := range fonts {
+		flavor := f.flavor
+		if flavor == ttcfTag {
+			flavor = 0x00010000
+		}
+		dir := out[dirOffsets[i]:]
+		numTables := len(f.tables)
+		entrySelector := 0
+		for 1<<(entrySelector+1) <= numTables {
+			entrySelector++
+		}
+		searchRange := 16 << entrySelector
+		binary.BigEndian.PutUint32(dir, flavor)
+		binary.BigEndian.PutUint16(dir[4:], uint16(numTables))
+		binary.BigEndian.PutUint16(dir[6:], uint16(searchRange))
+		binary.BigEndian.PutUint16(dir[8:], uint16(entrySelector))
+		binary.BigEndian.PutUint16(dir[10:], uint16(numTables*16-searchRange))
+
+		// Table records must be sorted by tag.
+		sorted := append([]int(nil), f.tables...)
+		sort.Slice(sorted, func(a, b int) bool { return tables[sorted[a]].tag < tables[sorted[b]].tag })
+		for j, ti := range sorted {
+			rec := dir[12+16*j:]
+			t := tables[ti]
+			binary.BigEndian.PutUint32(rec, t.tag)
+			binary.BigEndian.PutUint32(rec[4:], sfntChecksum(out[tableOffsets[ti]:tableOffsets[ti]+(len(t.data)+3)&^3]))
+			binary.BigEndian.PutUint32(rec[8:], uint32(tableOffsets[ti]))
+			binary.BigEndian.PutUint32(rec[12:], uint32(len(t.data)))
+		}
+	}
+	return out
+}
+
+// sfntChecksum is the OpenType table checksum: the sum of big-endian uint32s.
+func sfntChecksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i+4 <= len(b); i += 4 {
+		sum += binary.BigEndian.Uint32(b[i:])
+	}
+	return sum
+}
+
+// woffReader is a bounds-checked big-endian reader over WOFF data.
+type woffReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *woffReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, errInvalidWOFF
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *woffReader) u8() (uint8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *woffReader) u16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (r *woffReader) u32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// base128 reads a WOFF2 UIntBase128 value.
+func (r *woffReader) base128() (int, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.u8()
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 && b == 0x80 {
+			return 0, errInvalidWOFF // Leading zeros are not allowed
+		}
+		if v&0xfe000000 != 0 {
+			return 0, errInvalidWOFF // Would overflow
+		}
+		v = v<<7 | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			if v > maxDecodedFontSize {
+				return 0, errInvalidWOFF
+			}
+			return int(v), nil
+		}
+	}
+	return 0, errInvalidWOFF
+}
+
+// u255 reads a WOFF2 255UInt16 value.
+func (r *woffReader) u255() (int, error) {
+	code, err := r.u8()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 253:
+		v, err := r.u16()
+		return int(v), err
+	case 254:
+		v, err := r.u8()
+		return int(v) + 506, err
+	case 255:
+		v, err := r.u8()
+		return int(v) + 253, err
+	}
+	return int(code), nil
+}
+
+// bbox reads an explicit glyph bounding box (xMin, yMin, xMax, yMax).
+func (r *woffReader) bbox() ([4]int16, error) {
+	var bbox [4]int16
+	for i := range bbox {
+		v, err := r.u16()
+		if err != nil {
+			return bbox, err
+		}
+		bbox[i] = int16(v)
+	}
+	return bbox, nil
+}
+
+// triplet decodes one point delta of the transformed glyf table, whose
+// encoding is selected by the low 7 bits of the point's flag byte.
+func (r *woffReader) triplet(flag byte) (dx, dy int, err error) {
+	withSign := func(f byte, v int) int {
+		if f&1 != 0 {
+			return v
+		}
+		return -v
+	}
+
+	f := flag & 0x7f
+	var n int
+	switch {
+	case f < 84:
+		n = 1
+	case f < 120:
+		n = 2
+	case f < 124:
+		n = 3
+	default:
+		n = 4
+	}
+	b, err := r.bytes(n)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case f < 10:
+		dy = withSign(f, int(f&14)<<7+int(b[0]))
+	case f < 20:
+		dx = withSign(f, int((f-10)&14)<<7+int(b[0]))
+	case f < 84:
+		b0, b1 := int(f-20), int(b[0])
+		dx = withSign(f, 1+(b0&0x30)+(b1>>4))
+		dy = withSign(f>>1, 1+(b0&0x0c)<<2+(b1&0x0f))
+	case f < 120:
+		b0 := int(f - 84)
+		dx = withSign(f, 1+(b0/12)<<8+int(b[0]))
+		dy = withSign(f>>1, 1+((b0%12)>>2)<<8+int(b[1]))
+	case f < 124:
+		dx = withSign(f, int(b[0])<<4+int(b[1])>>4)
+		dy = withSign(f>>1, int(b[1]&0x0f)<<8+int(b[2]))
+	default:
+		dx = withSign(f, int(b[0])<<8+int(b[1]))
+		dy = withSign(f>>1, int(b[2])<<8+int(b[3]))
+	}
+	return dx, dy, nil
+}
+
+// collectionDirectory reads the WOFF2 CollectionHeader that follows the
+// table directory when the flavor is 'ttcf'.
+func (r *woffReader) collectionDirectory(numTables int) ([]woff2Font, error) {
+	if _, err := r.u32(); err != nil { // version
+		return nil, err
+	}
+	numFonts, err := r.u255()
+	if err != nil {
+		return nil, err
+	}
+	if numFonts == 0 {
+		return nil, errInvalidWOFF
+	}
+	fonts := make([]woff2Font, numFonts)
+	for i := range fonts {
+		n, err := r.u255()
+		if err != nil {
+			return nil, err
+		}
+		if fonts[i].flavor, err = r.u32(); err != nil {
+			return nil, err
+		}
+		for j := 0; j < n; j++ {
+			idx, err := r.u255()
+			if err != nil {
+				return nil, err
+			}
+			if idx >= numTables {
+				return nil, errInvalidWOFF
+			}
+			fonts[i].tables = append(fonts[i].tables, idx)
+		}
+	}
+	return fonts, nil
+}
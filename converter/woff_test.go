@@ -0,0 +1,372 @@
+package converter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// splitSFNT returns the flavor and the tables of a plain sfnt font.
+func splitSFNT(t *testing.T, font []byte) (uint32, []sfntTable) {
+	t.Helper()
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	tables := make([]sfntTable, numTables)
+	for i := range tables {
+		rec := font[12+16*i:]
+		offset := binary.BigEndian.Uint32(rec[8:])
+		length := binary.BigEndian.Uint32(rec[12:])
+		tables[i] = sfntTable{tag: binary.BigEndian.Uint32(rec), data: font[offset : offset+length]}
+	}
+	return binary.BigEndian.Uint32(font), tables
+}
+
+// encodeWOFF1 builds a WOFF 1.0 file, zlib-compressing the tables that shrink.
+func encodeWOFF1(t *testing.T, font []byte) []byte {
+	flavor, tables := splitSFNT(t, font)
+	header := make([]byte, 44+20*len(tables))
+	binary.BigEndian.PutUint32(header, woffSignature)
+	binary.BigEndian.PutUint32(header[4:], flavor)
+	binary.BigEndian.PutUint16(header[12:], uint16(len(tables)))
+
+	var body []byte
+	for i, tbl := range tables {
+		var zbuf bytes.Buffer
+		zw := zlib.NewWriter(&zbuf)
+		_, _ = zw.Write(tbl.data)
+		_ = zw.Close()
+		if zbuf.Len() >= len(tbl.data) {
+			zbuf.Reset()
+			zbuf.Write(tbl.data)
+		}
+
+		e := header[44+20*i:]
+		binary.BigEndian.PutUint32(e, tbl.tag)
+		binary.BigEndian.PutUint32(e[4:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(e[8:], uint32(zbuf.Len()))
+		binary.BigEndian.PutUint32(e[12:], uint32(len(tbl.data)))
+		body = append(body, zbuf.Bytes()...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	out := append(header, body...)
+	binary.BigEndian.PutUint32(out[8:], uint32(len(out)))
+	return out
+}
+
+// encodeWOFF2 builds a WOFF 2.0 file, applying the glyf/loca and hmtx
+// transforms so the decoder's reconstruction paths are exercised.
+func encodeWOFF2(t *testing.T, font []byte) []byte {
+	flavor, tables := splitSFNT(t, font)
+	byTag := map[uint32][]byte{}
+	for _, tbl := range tables {
+		byTag[tbl.tag] = tbl.data
+	}
+
+	var dir, stream []byte
+	for _, tbl := range tables {
+		data, transformed := tbl.data, false
+		switch tbl.tag {
+		case tagGlyf:
+			data, transformed = transformGlyf(byTag[tagGlyf], byTag[tagLoca], byTag[tagHead]), true
+		case tagLoca:
+			data, transformed = nil, true
+		case tagHmtx:
+			data, transformed = transformHmtx(byTag[tagHhea], byTag[tagMaxp], tbl.data), true
+		}
+
+		flags := byte(0x3f)
+		for i, known := range woff2KnownTags {
+			if tagOf(known) == tbl.tag {
+				flags = byte(i)
+			}
+		}
+		switch {
+		case (tbl.tag == tagGlyf || tbl.tag == tagLoca) && !transformed:
+			flags |= 3 << 6
+		case tbl.tag == tagHmtx && transformed:
+			flags |= 1 << 6
+		}
+		dir = append(dir, flags)
+		if flags&0x3f == 0x3f {
+			dir = binary.BigEndian.AppendUint32(dir, tbl.tag)
+		}
+		dir = appendBase128(dir, len(tbl.data))
+		if transformed {
+			dir = appendBase128(dir, len(data))
+		}
+		stream = append(stream, data...)
+	}
+
+	var compressed bytes.Buffer
+	bw := brotli.NewWriter(&compressed)
+	_, _ = bw.Write(stream)
+	_ = bw.Close()
+
+	header := make([]byte, 48)
+	binary.BigEndian.PutUint32(header, woff2Signature)
+	binary.BigEndian.PutUint32(header[4:], flavor)
+	binary.BigEndian.PutUint16(header[12:], uint16(len(tables)))
+	binary.BigEndian.PutUint32(header[20:], uint32(compressed.Len()))
+	out := append(append(header, dir...), compressed.Bytes()...)
+	binary.BigEndian.PutUint32(out[8:], uint32(len(out)))
+	return out
+}
+
+func appendBase128(b []byte, v int) []byte {
+	var tmp []byte
+	for {
+		tmp = append([]byte{byte(v & 0x7f)}, tmp...)
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := 0; i < len(tmp)-1; i++ {
+		tmp[i] |= 0x80
+	}
+	return append(b, tmp...)
+}
+
+func append255(b []byte, v int) []byte {
+	return binary.BigEndian.AppendUint16(append(b, 253), uint16(v))
+}
+
+// transformGlyf produces the WOFF2 transformed glyf table. Every point uses
+// the 4-byte triplet form and every non-empty glyph stores its bbox.
+func transformGlyf(glyf, loca, head []byte) []byte {
+	longLoca := binary.BigEndian.Uint16(head[50:]) != 0
+	numGlyphs := len(loca)/2 - 1
+	offset := func(i int) int { return 2 * int(binary.BigEndian.Uint16(loca[2*i:])) }
+	if longLoca {
+		numGlyphs = len(loca)/4 - 1
+		offset = func(i int) int { return int(binary.BigEndian.Uint32(loca[4*i:])) }
+	}
+
+	var streams [7][]byte
+	bboxBitmap := make([]byte, 4*((numGlyphs+31)/32))
+	var bboxes []byte
+	for g := 0; g < numGlyphs; g++ {
+		data := glyf[offset(g):offset(g+1)]
+		if len(data) == 0 {
+			streams[0] = binary.BigEndian.AppendUint16(streams[0], 0)
+			continue
+		}
+		contours := int(int16(binary.BigEndian.Uint16(data)))
+		streams[0] = binary.BigEndian.AppendUint16(streams[0], uint16(int16(contours)))
+		bboxBitmap[g>>3] |= 0x80 >> (g & 7)
+		bboxes = append(bboxes, data[2:10]...)
+
+		if contours < 0 {
+			// Component records go verbatim to the composite stream.
+			p := 10
+			hasInstructions := false
+			for {
+				flags := binary.BigEndian.Uint16(data[p:])
+				n := 4
+				if flags&1 != 0 {
+					n += 4
+				} else {
+					n += 2
+				}
+				switch {
+				case flags&0x08 != 0:
+					n += 2
+				case flags&0x40 != 0:
+					n += 4
+				case flags&0x80 != 0:
+					n += 8
+				}
+				streams[4] = append(streams[4], data[p:p+n]...)
+				p += n
+				hasInstructions = hasInstructions || flags&0x100 != 0
+				if flags&0x20 == 0 {
+					break
+				}
+			}
+			if hasInstructions {
+				n := int(binary.BigEndian.Uint16(data[p:]))
+				streams[3] = append255(streams[3], n)
+				streams[6] = append(streams[6], data[p+2:p+2+n]...)
+			}
+			continue
+		}
+
+		endPts, instr, pts := parseSimpleGlyph(data)
+		prev := -1
+		for _, e := range endPts {
+			streams[1] = append255(streams[1], e-prev)
+			prev = e
+		}
+		px, py := 0, 0
+		for _, p := range pts {
+			dx, dy := p.x-px, p.y-py
+			px, py = p.x, p.y
+			flag := byte(124)
+			if dx >= 0 {
+				flag |= 1
+			}
+			if dy >= 0 {
+				flag |= 2
+			}
+			if !p.onCurve {
+				flag |= 0x80
+			}
+			streams[2] = append(streams[2], flag)
+			streams[3] = append(streams[3], byte(abs(dx)>>8), byte(abs(dx)), byte(abs(dy)>>8), byte(abs(dy)))
+		}
+		streams[3] = append255(streams[3], len(instr))
+		streams[6] = append(streams[6], instr...)
+	}
+	streams[5] = append(bboxBitmap, bboxes...)
+
+	out := make([]byte, 8+4*len(streams))
+	binary.BigEndian.PutUint16(out[4:], uint16(numGlyphs))
+	if longLoca {
+		binary.BigEndian.PutUint16(out[6:], 1)
+	}
+	for i, s := range streams {
+		binary.BigEndian.PutUint32(out[8+4*i:], uint32(len(s)))
+	}
+	for _, s := range streams {
+		out = append(out, s...)
+	}
+	return out
+}
+
+// parseSimpleGlyph decodes the points of a simple glyf glyph.
+func parseSimpleGlyph(data []byte) ([]int, []byte, []glyfPoint) {
+	contours := int(binary.BigEndian.Uint16(data))
+	endPts := make([]int, contours)
+	p := 10
+	for i := range endPts {
+		endPts[i] = int(binary.BigEndian.Uint16(data[p:]))
+		p += 2
+	}
+	n := int(binary.BigEndian.Uint16(data[p:]))
+	instr := data[p+2 : p+2+n]
+	p += 2 + n
+
+	numPts := endPts[len(endPts)-1] + 1
+	flags := make([]byte, 0, numPts)
+	for len(flags) < numPts {
+		f := data[p]
+		p++
+		flags = append(flags, f)
+		if f&0x08 != 0 {
+			for r := data[p]; r > 0; r-- {
+				flags = append(flags, f)
+			}
+			p++
+		}
+	}
+
+	pts := make([]glyfPoint, numPts)
+	coord := func(short, same byte, set func(i, v int)) {
+		v := 0
+		for i, f := range flags {
+			switch {
+			case f&short != 0:
+				d := int(data[p])
+				p++
+				if f&same == 0 {
+					d = -d
+				}
+				v += d
+			case f&same == 0:
+				v += int(int16(binary.BigEndian.Uint16(data[p:])))
+				p += 2
+			}
+			set(i, v)
+		}
+	}
+	coord(0x02, 0x10, func(i, v int) { pts[i].x = v })
+	coord(0x04, 0x20, func(i, v int) { pts[i].y = v })
+	for i, f := range flags {
+		pts[i].onCurve = f&0x01 != 0
+	}
+	return endPts, instr, pts
+}
+
+// transformHmtx drops all left side bearings, which the decoder must then
+// restore from the glyph bounding boxes.
+func transformHmtx(hhea, maxp, hmtx []byte) []byte {
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	out := []byte{0x03}
+	for i := 0; i < numHMetrics; i++ {
+		out = append(out, hmtx[4*i:4*i+2]...)
+	}
+	return out
+}
+
+// glyphSignature summarises a font's glyph outlines and advances, so two
+// fonts can be checked for equivalence regardless of their byte layout.
+func glyphSignature(t *testing.T, data []byte) []string {
+	t.Helper()
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		t.Fatalf("parsing decoded font: %v", err)
+	}
+	var buf sfnt.Buffer
+	var sig []string
+	ppem := fixed.I(int(f.UnitsPerEm()))
+	for g := 0; g < f.NumGlyphs(); g++ {
+		segs, err := f.LoadGlyph(&buf, sfnt.GlyphIndex(g), ppem, nil)
+		if err != nil {
+			t.Fatalf("glyph %d: %v", g, err)
+		}
+		adv, _ := f.GlyphAdvance(&buf, sfnt.GlyphIndex(g), ppem, 0)
+		sig = append(sig, fmtSegments(segs, adv))
+	}
+	return sig
+}
+
+func fmtSegments(segs sfnt.Segments, adv fixed.Int26_6) string {
+	var b bytes.Buffer
+	b.WriteString(adv.String())
+	for _, s := range segs {
+		b.WriteString(" ")
+		b.WriteByte(byte('0' + s.Op))
+		for _, a := range s.Args {
+			b.WriteString(a.X.String() + "," + a.Y.String())
+		}
+	}
+	return b.String()
+}
+
+func TestDecodeWOFF(t *testing.T) {
+	want := glyphSignature(t, goregular.TTF)
+
+	for name, data := range map[string][]byte{
+		"woff":  encodeWOFF1(t, goregular.TTF),
+		"woff2": encodeWOFF2(t, goregular.TTF),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if !isWOFF(data) {
+				t.Fatal("isWOFF() = false")
+			}
+			decoded, err := decodeWOFF(data)
+			if err != nil {
+				t.Fatalf("decodeWOFF() failed: %v", err)
+			}
+			if got := glyphSignature(t, decoded); !reflect.DeepEqual(got, want) {
+				t.Error("decoded glyphs differ from the original font")
+			}
+
+			path := writeTempFont(t, "Go-Regular."+name, data)
+			outPrefix := filepath.Join(t.TempDir(), "web")
+			opts := Options{Size: 16, Chars: "AB", Format: "png", Spacing: [2]int{2, 2}, Hinting: "full"}
+			if err := GenerateWithOptions(path, outPrefix, opts); err != nil {
+				t.Fatalf("GenerateWithOptions() failed: %v", err)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ module ttf2bmp
 
 go 1.25
 
-require golang.org/x/image v0.31.0
-
-require golang.org/x/text v0.29.0 // indirect
+require (
+	github.com/andybalholm/brotli v1.2.0
+	golang.org/x/image v0.31.0
+	golang.org/x/text v0.29.0
+)
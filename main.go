@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"ttf2bmp/converter"
@@ -18,136 +27,1344 @@ var Version = "dev"
 
 type Config struct {
 	FontPattern string
-	Sizes       []int
+	Sizes       []float64
 	Chars       string
 	OutputDir   string
 	Format      string
-	Padding     int
+	Padding     [4]int // Up, right, down, left
+	Spacing     [2]int // Horizontal, vertical
 	Hinting     string // New field
+	FaceIndex   int
+	AllFaces    bool
+
+	// Variable fonts
+	NamedInstance string
+	Axes          []converter.AxisValue
+
+	// Output naming, e.g. "{family}-{style}-{size}"
+	NameTemplate string
+
+	// Convert fonts whose license (OS/2 fsType) forbids embedding
+	AllowRestricted bool
+
+	// Write a JSON metadata sidecar next to each .fnt
+	EmitMeta bool
+
+	// Supersampled rendering (factor <= 1 disables it)
+	Supersample       int
+	SupersampleFilter string
+
+	// Antialiasing: "on", "off" or "auto", and the coverage threshold for 1-bit glyphs
+	Antialias string
+	Threshold int
+
+	// LCD subpixel rendering: "", "rgb" or "bgr"
+	LCD string
+
+	// Coverage remapping and alpha storage ("" keeps the format default)
+	Gamma    float64
+	Contrast float64
+	Alpha    string
+
+	// Synthetic bold (pixels) and oblique (degrees)
+	Embolden float64
+	Oblique  float64
+
+	// Sizing: DPI for point sizes, or "line"/"cap" for pixel heights
+	DPI      float64
+	SizeMode string
+
+	// Texture budget (--fit WxH); replaces Sizes with the largest size that fits
+	FitWidth  int
+	FitHeight int
+
+	// Fixed cells: "left"/"center" alignment ("" proportional) and width (0: widest)
+	Monospace string
+	CellWidth int
+
+	// Tileset sheet code page ("" for BMFont atlases), its tile listing, and
+	// the tiles --sheet-map remaps (read from SheetMapFile)
+	Sheet        string
+	SheetListing bool
+	SheetMap     map[int]rune
+	SheetMapFile string
+
+	// Legacy code page for char ids ("" for Unicode)
+	Charset string
+
+	// Jobs is how many conversions run at once (-j)
+	Jobs int
+
+	// Rebuild every job, even those the manifest says are up to date
+	Force bool
+
+	// Keep running, converting again whenever the fonts or settings change
+	Watch bool
+
+	// Settings (--config) and characters (--chars-file) files, "" if unused
+	ConfigFile string
+	CharsFile  string
+
+	// Notes about the settings (e.g. deprecated flags), listed with the
+	// warnings of every batch
+	Notices []string
 }
 
-var logBuffer []string
+// FontInput is a single face to convert: a font file plus the face to pick
+// from it when the file is a .ttc/.otc collection.
+type FontInput struct {
+	Path      string
+	FaceIndex int
+	Name      string // Output base name
+}
 
-func main() {
-	var fontsFlag, sizesFlag, charsFlag, outDir, typeFlag, hintingFlag string
-	var paddingFlag int
-	var showVersion bool
+var logBuffer []string // Dashboard status lines, one per worker
 
+func main() {
 	flag.Usage = func() {
 		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s (%s):\n", "ttf2bmp", Version)
 		flag.PrintDefaults()
 	}
 
-	flag.StringVar(&fontsFlag, "fonts", "", "Glob pattern (e.g. 'assets/*.ttf')")
-	flag.StringVar(&fontsFlag, "f", "", "Short for --fonts")
-	flag.StringVar(&sizesFlag, "sizes", "", "Comma sizes (e.g. '12,24')")
-	flag.StringVar(&sizesFlag, "s", "", "Short for --sizes")
-	flag.StringVar(&charsFlag, "chars", "", "Characters to include")
-	flag.StringVar(&charsFlag, "c", "", "Short for --chars")
-	flag.StringVar(&outDir, "out", ".", "Output dir")
-	flag.StringVar(&outDir, "o", ".", "Short for --out")
-	flag.StringVar(&typeFlag, "type", "png", "Output type: 'png' or 'bmp'")
-	flag.StringVar(&typeFlag, "t", "png", "Short for --type")
-	flag.IntVar(&paddingFlag, "padding", 2, "Padding between characters (pixels)")
-	flag.IntVar(&paddingFlag, "p", 2, "Short for --padding")
-
-	// NEW: Hinting flag
-	flag.StringVar(&hintingFlag, "hinting", "full", "Hinting: 'none' (smooth) or 'full' (crisp)")
-	flag.StringVar(&hintingFlag, "h", "full", "Short for --hinting")
-
-	flag.BoolVar(&showVersion, "version", false, "Print version")
-
+	v := defineFlags(flag.CommandLine)
 	flag.Parse()
 
-	if showVersion {
+	if v.showVersion {
 		fmt.Printf("ttf2bmp version %s\n", Version)
 		os.Exit(0)
 	}
 
-	cfg, err := validateInputs(fontsFlag, sizesFlag, charsFlag, outDir, typeFlag, paddingFlag, hintingFlag)
+	cfg, err := v.config(flag.CommandLine)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	// Ctrl+C stops the running jobs (and --watch).
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if cfg.Watch {
+		watch(ctx, cfg)
+		return
+	}
+	pattern, hasIndex := fontPattern(&cfg)
+
 	// Glob / File detection
-	files, err := filepath.Glob(cfg.FontPattern)
+	files, err := findFonts(pattern)
 	if err != nil {
 		fmt.Printf("Glob error: %v\n", err)
 		os.Exit(1)
 	}
 	if len(files) == 0 {
-		if _, err := os.Stat(cfg.FontPattern); err == nil {
-			files = []string{cfg.FontPattern}
-		} else {
-			fmt.Printf("No fonts found for pattern: %s\n", cfg.FontPattern)
-			os.Exit(0)
+		fmt.Printf("No fonts found for pattern: %s\n", cfg.FontPattern)
+		os.Exit(0)
+	}
+
+	inputs, err := expandInputs(files, cfg, hasIndex)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !processBatch(ctx, inputs, cfg) {
+		os.Exit(1)
+	}
+}
+
+// flagValues holds the command-line flags before they are checked.
+type flagValues struct {
+	fontsFlag, sizesFlag, charsFlag, charsFileFlag, configFlag, outDir, typeFlag, hintingFlag string
+	axesFlag, instanceFlag, nameFlag, filterFlag, aaFlag, lcdFlag, alphaFlag                  string
+	gammaFlag, contrastFlag, emboldenFlag, obliqueFlag, dpiFlag                               float64
+	sizeModeFlag, fitFlag, paddingFlag, spacingFlag, monospaceFlag                            string
+	faceIndexFlag, supersampleFlag, thresholdFlag, cellWidthFlag, jobsFlag                    int
+	sheetFlag, sheetMapFlag, charsetFlag                                                      string
+	showVersion, allFacesFlag, allowRestrictedFlag, emitMetaFlag, sheetListingFlag            bool
+	forceFlag, watchFlag                                                                      bool
+}
+
+// defineFlags defines the command-line flags on fs.
+func defineFlags(fs *flag.FlagSet) *flagValues {
+	v := &flagValues{}
+	fs.StringVar(&v.fontsFlag, "fonts", "", "Glob pattern (e.g. 'assets/*.ttf')")
+	fs.StringVar(&v.fontsFlag, "f", "", "Short for --fonts")
+	fs.StringVar(&v.sizesFlag, "sizes", "", "Comma sizes (e.g. '12,24' or '10.5')")
+	fs.StringVar(&v.sizesFlag, "s", "", "Short for --sizes")
+	fs.Float64Var(&v.dpiFlag, "dpi", 72, "Resolution for point sizes (72: 1pt = 1px)")
+	fs.StringVar(&v.sizeModeFlag, "size-mode", "pt", "Size meaning: 'pt' (points), 'line' or 'cap' (pixel line/cap height)")
+	fs.StringVar(&v.fitFlag, "fit", "", "Texture budget WxH: use the largest size that fits (instead of --sizes)")
+	fs.StringVar(&v.charsFlag, "chars", "", "Characters to include")
+	fs.StringVar(&v.charsFlag, "c", "", "Short for --chars")
+	fs.StringVar(&v.charsFileFlag, "chars-file", "", "File with more characters to include (UTF-8, line breaks ignored)")
+	fs.StringVar(&v.outDir, "out", ".", "Output dir")
+	fs.StringVar(&v.outDir, "o", ".", "Short for --out")
+	fs.StringVar(&v.typeFlag, "type", "png", "Output type: 'png' or 'bmp'")
+	fs.StringVar(&v.typeFlag, "t", "png", "Short for --type")
+	fs.StringVar(&v.paddingFlag, "padding", "0", "Padding around each character: 'N' or 'up,right,down,left' (pixels)")
+	fs.StringVar(&v.spacingFlag, "spacing", "2", "Spacing between characters: 'N' or 'horizontal,vertical' (pixels)")
+	fs.StringVar(&v.spacingFlag, "p", "2", "Short for --spacing")
+
+	// Fixed cells
+	fs.StringVar(&v.monospaceFlag, "monospace", "", "Fixed-width cells with glyphs aligned 'left' or 'center'")
+	fs.IntVar(&v.cellWidthFlag, "cell-width", 0, "Fixed cell width in pixels (default: the widest glyph)")
+
+	// Char ids
+	fs.StringVar(&v.charsetFlag, "charset", "", "Write char ids in a legacy code page (e.g. 'windows-1252', 'ISO-8859-2', 'Shift_JIS')")
+
+	// Tileset sheets
+	fs.StringVar(&v.sheetFlag, "sheet", "", "Export a 16x16 tileset sheet of a code page ('cp437') instead of a BMFont")
+	fs.BoolVar(&v.sheetListingFlag, "sheet-listing", false, "Also write a text listing (<name>.txt) of each tile's index and code point")
+	fs.StringVar(&v.sheetMapFlag, "sheet-map", "", "File of '0x41 U+0041' lines overriding the code point of sheet tiles")
+
+	// NEW: Hinting flag
+	fs.StringVar(&v.hintingFlag, "hinting", "full", "Hinting: 'none' (smooth) or 'full' (crisp)")
+	fs.StringVar(&v.hintingFlag, "h", "full", "Short for --hinting")
+
+	// Collections (.ttc/.otc)
+	fs.IntVar(&v.faceIndexFlag, "face-index", 0, "Face to use from font collections (or use 'file.ttc#N')")
+	fs.BoolVar(&v.allFacesFlag, "all-faces", false, "Convert every face of font collections")
+
+	// Variable fonts
+	fs.StringVar(&v.axesFlag, "axes", "", "Variable font axis values (e.g. 'wght=700,wdth=85')")
+	fs.StringVar(&v.instanceFlag, "named-instance", "", "Variable font named instance (e.g. 'Bold Condensed')")
+
+	// Output naming
+	fs.StringVar(&v.nameFlag, "name", "{file}-{size}", "Output name template: {file}, {family}, {style}, {full}, {size}")
+
+	// Licensing
+	fs.BoolVar(&v.allowRestrictedFlag, "allow-restricted", false, "Convert fonts whose license forbids embedding")
+
+	// Metadata
+	fs.BoolVar(&v.emitMetaFlag, "emit-meta", false, "Write a JSON metadata sidecar for each font")
+
+	// Rendering
+	fs.IntVar(&v.supersampleFlag, "supersample", 1, "Render glyphs at N times the size and filter them down")
+	fs.StringVar(&v.filterFlag, "supersample-filter", "box", "Supersample filter: 'box' or 'lanczos'")
+	fs.StringVar(&v.aaFlag, "aa", "on", "Antialiasing: 'on', 'off' (1-bit glyphs) or 'auto' (off for pixel fonts)")
+	fs.IntVar(&v.thresholdFlag, "aa-threshold", 128, "Coverage (1-255) at which a pixel is set when antialiasing is off")
+	fs.StringVar(&v.lcdFlag, "lcd", "", "LCD subpixel rendering: 'rgb' or 'bgr'")
+	fs.Float64Var(&v.gammaFlag, "gamma", 1, "Coverage gamma (above 1 thickens glyphs)")
+	fs.Float64Var(&v.contrastFlag, "contrast", 1, "Coverage contrast (above 1 sharpens edges)")
+	fs.Float64Var(&v.emboldenFlag, "embolden", 0, "Synthetic bold: widen stems by this many pixels (e.g. 0.5)")
+	fs.Float64Var(&v.obliqueFlag, "oblique", 0, "Synthetic oblique: slant angle in degrees (e.g. 12)")
+	fs.StringVar(&v.alphaFlag, "alpha", "", "Alpha storage: 'premultiplied' or 'straight' (default: straight PNG, premultiplied BMP)")
+
+	// Concurrency
+	fs.IntVar(&v.jobsFlag, "jobs", runtime.NumCPU(), "Number of conversions to run in parallel")
+	fs.IntVar(&v.jobsFlag, "j", runtime.NumCPU(), "Short for --jobs")
+	fs.BoolVar(&v.forceFlag, "force", false, "Rebuild every job, even if the manifest says it is up to date")
+	fs.BoolVar(&v.watchFlag, "watch", false, "Keep running and convert again whenever the fonts, --config, --chars-file or --sheet-map change")
+
+	// Settings file
+	fs.StringVar(&v.configFlag, "config", "", "File of settings, one 'flag = value' per line (the command line wins)")
+
+	fs.BoolVar(&v.showVersion, "version", false, "Print version")
+	return v
+}
+
+// reloadConfig parses the command line again, re-reading the --config and
+// --chars-file files, for --watch to pick up their changes.
+func reloadConfig() (Config, error) {
+	fs := flag.NewFlagSet("ttf2bmp", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	v := defineFlags(fs)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return Config{}, err
+	}
+	return v.config(fs)
+}
+
+// config checks the flags and turns them into a Config. Flags the command
+// line leaves unset are first taken from the --config file.
+func (v *flagValues) config(fs *flag.FlagSet) (Config, error) {
+	if v.configFlag != "" {
+		if err := applyConfigFile(fs, v.configFlag); err != nil {
+			return Config{}, err
+		}
+	}
+
+	// Characters come from --chars and the --chars-file, in that order.
+	chars := v.charsFlag
+	if v.charsFileFlag != "" {
+		data, err := os.ReadFile(v.charsFileFlag)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading characters file: %w", err)
+		}
+		chars = mergeChars(chars, string(data))
+	}
+
+	cfg, err := validateInputs(v.fontsFlag, v.sizesFlag, chars, v.outDir, v.typeFlag, v.paddingFlag, v.spacingFlag, v.hintingFlag)
+	if err == nil {
+		cfg.FitWidth, cfg.FitHeight, err = parseFit(v.fitFlag)
+	}
+	if err == nil && (len(cfg.Sizes) > 0) == (cfg.FitWidth > 0) {
+		err = fmt.Errorf("use either --sizes or --fit")
+	}
+	if err == nil && cfg.Chars == "" && v.sheetFlag == "" {
+		err = fmt.Errorf("missing arguments")
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if v.faceIndexFlag < 0 {
+		return Config{}, fmt.Errorf("face index cannot be negative")
+	}
+	cfg.FaceIndex = v.faceIndexFlag
+	cfg.AllFaces = v.allFacesFlag
+
+	cfg.AllowRestricted = v.allowRestrictedFlag
+	cfg.EmitMeta = v.emitMetaFlag
+	if v.supersampleFlag < 1 || v.supersampleFlag > 16 {
+		return Config{}, fmt.Errorf("supersample factor must be between 1 and 16")
+	}
+	if v.filterFlag != "box" && v.filterFlag != "lanczos" {
+		return Config{}, fmt.Errorf("supersample filter must be 'box' or 'lanczos'")
+	}
+	cfg.Supersample, cfg.SupersampleFilter = v.supersampleFlag, v.filterFlag
+	if v.aaFlag != "on" && v.aaFlag != "off" && v.aaFlag != "auto" {
+		return Config{}, fmt.Errorf("aa must be 'on', 'off' or 'auto'")
+	}
+	if v.thresholdFlag < 1 || v.thresholdFlag > 255 {
+		return Config{}, fmt.Errorf("aa threshold must be between 1 and 255")
+	}
+	cfg.Antialias, cfg.Threshold = v.aaFlag, v.thresholdFlag
+	switch {
+	case v.lcdFlag != "" && v.lcdFlag != "rgb" && v.lcdFlag != "bgr":
+		return Config{}, fmt.Errorf("lcd must be 'rgb' or 'bgr'")
+	case v.lcdFlag != "" && (cfg.Supersample > 1 || cfg.Antialias != "on"):
+		return Config{}, fmt.Errorf("lcd cannot be combined with --supersample or --aa=off/auto")
+	}
+	cfg.LCD = v.lcdFlag
+	if v.gammaFlag <= 0 || v.gammaFlag > 10 || v.contrastFlag <= 0 || v.contrastFlag > 10 {
+		return Config{}, fmt.Errorf("gamma and contrast must be greater than 0 and at most 10")
+	}
+	if v.alphaFlag != "" && v.alphaFlag != converter.AlphaPremultiplied && v.alphaFlag != converter.AlphaStraight {
+		return Config{}, fmt.Errorf("alpha must be 'premultiplied' or 'straight'")
+	}
+	cfg.Gamma, cfg.Contrast, cfg.Alpha = v.gammaFlag, v.contrastFlag, v.alphaFlag
+	if v.emboldenFlag < 0 || v.emboldenFlag > 16 || v.obliqueFlag < -45 || v.obliqueFlag > 45 {
+		return Config{}, fmt.Errorf("embolden must be between 0 and 16 pixels, oblique between -45 and 45 degrees")
+	}
+	cfg.Embolden, cfg.Oblique = v.emboldenFlag, v.obliqueFlag
+	if v.dpiFlag <= 0 || v.dpiFlag > 2400 {
+		return Config{}, fmt.Errorf("dpi must be greater than 0 and at most 2400")
+	}
+	switch v.sizeModeFlag {
+	case "pt":
+		cfg.SizeMode = converter.SizePoints
+	case converter.SizeLineHeight, converter.SizeCapHeight:
+		cfg.SizeMode = v.sizeModeFlag
+	default:
+		return Config{}, fmt.Errorf("size mode must be 'pt', 'line' or 'cap'")
+	}
+	cfg.DPI = v.dpiFlag
+	switch {
+	case v.monospaceFlag != "" && v.monospaceFlag != converter.MonospaceLeft && v.monospaceFlag != converter.MonospaceCenter:
+		return Config{}, fmt.Errorf("monospace must be 'left' or 'center'")
+	case v.cellWidthFlag < 0 || v.cellWidthFlag > 4096:
+		return Config{}, fmt.Errorf("cell width must be between 1 and 4096")
+	case v.cellWidthFlag > 0 && v.monospaceFlag == "":
+		return Config{}, fmt.Errorf("--cell-width requires --monospace")
+	}
+	cfg.Monospace, cfg.CellWidth = v.monospaceFlag, v.cellWidthFlag
+	switch {
+	case v.sheetFlag != "" && v.sheetFlag != converter.SheetCP437:
+		return Config{}, fmt.Errorf("sheet must be 'cp437'")
+	case v.sheetFlag != "" && cfg.FitWidth > 0:
+		return Config{}, fmt.Errorf("--sheet cannot be combined with --fit")
+	case v.sheetListingFlag && v.sheetFlag == "":
+		return Config{}, fmt.Errorf("--sheet-listing requires --sheet")
+	case v.sheetMapFlag != "" && v.sheetFlag == "":
+		return Config{}, fmt.Errorf("--sheet-map requires --sheet")
+	}
+	cfg.Sheet, cfg.SheetListing = v.sheetFlag, v.sheetListingFlag
+	if v.sheetMapFlag != "" {
+		if cfg.SheetMap, err = converter.ReadSheetMap(v.sheetMapFlag); err != nil {
+			return Config{}, err
 		}
+		cfg.SheetMapFile = v.sheetMapFlag
 	}
+	if err := converter.ValidateCharset(v.charsetFlag); err != nil {
+		return Config{}, err
+	}
+	if v.charsetFlag != "" && v.sheetFlag != "" {
+		return Config{}, fmt.Errorf("--charset cannot be combined with --sheet")
+	}
+	cfg.Charset = v.charsetFlag
+	if v.jobsFlag < 1 {
+		return Config{}, fmt.Errorf("jobs must be at least 1")
+	}
+	cfg.Jobs = v.jobsFlag
+	cfg.Force = v.forceFlag
+	cfg.Watch = v.watchFlag
+	cfg.NameTemplate = v.nameFlag
+	if !strings.Contains(v.nameFlag, "{size}") && len(cfg.Sizes) > 1 {
+		return Config{}, fmt.Errorf("name template must contain {size} when converting several sizes")
+	}
+
+	cfg.NamedInstance = strings.TrimSpace(v.instanceFlag)
+	if cfg.Axes, err = converter.ParseAxes(v.axesFlag); err != nil {
+		return Config{}, err
+	}
+	cfg.ConfigFile, cfg.CharsFile = v.configFlag, v.charsFileFlag
 
-	processBatch(files, cfg)
+	// Until padding and spacing were separate, --padding set the spacing.
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		given[longFlag(f.Name)] = true
+	})
+	if given["padding"] && !given["spacing"] {
+		cfg.Notices = append(cfg.Notices, "WARN --padding without --spacing is deprecated: --padding now adds pixels inside each glyph's "+
+			"rectangle; use --spacing (or -p) for the gap between glyphs, or give both to silence this warning")
+	}
+	return cfg, nil
 }
 
-func processBatch(files []string, cfg Config) {
-	totalJobs := len(files) * len(cfg.Sizes)
-	currentJob := 0
-	successCount := 0
-	var failures []string
+// flagAliases maps the short flags to the long ones.
+var flagAliases = map[string]string{
+	"f": "fonts", "s": "sizes", "c": "chars", "o": "out", "t": "type", "p": "spacing", "h": "hinting", "j": "jobs",
+}
 
-	// UI Setup
-	logBuffer = make([]string, 5)
-	fmt.Print("\n\n\n\n\n\n")
-	fmt.Print("\033[?25l")
-	defer fmt.Print("\033[?25h")
+// applyConfigFile sets the flags listed in a settings file, one
+// "name = value" per line ("name" alone turns a boolean on), unless the
+// command line sets them. Blank lines and lines starting with '#' are
+// skipped; a value in double quotes may use Go escapes (e.g. "\n").
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[longFlag(f.Name)] = true
+	})
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(line, "=")
+		name, value = strings.TrimPrefix(strings.TrimSpace(name), "--"), strings.TrimSpace(value)
+		if !hasValue {
+			value = "true"
+		} else if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("%s:%d: invalid quoted value", path, i+1)
+			}
+		}
+		switch {
+		case fs.Lookup(name) == nil:
+			return fmt.Errorf("%s:%d: unknown setting %q", path, i+1, name)
+		case longFlag(name) == "config" || longFlag(name) == "version":
+			return fmt.Errorf("%s:%d: %s can only be given on the command line", path, i+1, name)
+		case explicit[longFlag(name)]:
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value for %s: %w", path, i+1, name, err)
+		}
+	}
+	return nil
+}
+
+// longFlag returns the long name of a flag.
+func longFlag(name string) string {
+	if long, ok := flagAliases[name]; ok {
+		return long
+	}
+	return name
+}
+
+// mergeChars appends the characters of a --chars-file to the --chars ones,
+// leaving out line breaks and characters already listed.
+func mergeChars(chars, file string) string {
+	seen := map[rune]bool{}
+	for _, r := range chars {
+		seen[r] = true
+	}
+	var b strings.Builder
+	b.WriteString(chars)
+	for _, r := range strings.TrimPrefix(file, "\uFEFF") {
+		if r == '\n' || r == '\r' || seen[r] {
+			continue
+		}
+		seen[r] = true
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// fontPattern returns the --fonts pattern to glob. A "#N" suffix selects a
+// face from a collection and overrides --face-index.
+func fontPattern(cfg *Config) (string, bool) {
+	pattern, faceIndex, hasIndex := splitFaceIndex(cfg.FontPattern)
+	if hasIndex {
+		cfg.FaceIndex = faceIndex
+	}
+	return pattern, hasIndex
+}
+
+// findFonts resolves the fonts pattern. A directory expands to every font
+// file inside it (.ttf, .otf, .ttc, .otc, .woff, .woff2); a file that
+// doesn't match as a pattern (e.g. with "[" in its name) is used as is.
+func findFonts(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, e := range entries {
+			if !e.IsDir() && converter.IsFontFile(e.Name()) {
+				files = append(files, filepath.Join(pattern, e.Name()))
+			}
+		}
+		return files, nil
+	}
+	files, err := filepath.Glob(pattern)
+	if err == nil && len(files) == 0 {
+		if info, err := os.Stat(pattern); err == nil && !info.IsDir() {
+			files = []string{pattern}
+		}
+	}
+	return files, err
+}
+
+// splitFaceIndex splits a "fonts/file.ttc#2" pattern into the path and face
+// index. Only a trailing "#<digits>" is an index, and a path that exists as
+// written (e.g. "Font#1") is left alone, so '#' elsewhere is just a character.
+func splitFaceIndex(pattern string) (string, int, bool) {
+	i := strings.LastIndex(pattern, "#")
+	if i < 0 {
+		return pattern, 0, false
+	}
+	if _, err := os.Stat(pattern); err == nil {
+		return pattern, 0, false
+	}
+	digits := pattern[i+1:]
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return pattern, 0, false
+	}
+	idx, err := strconv.Atoi(digits)
+	if err != nil {
+		return pattern, 0, false
+	}
+	return pattern[:i], idx, true
+}
+
+// expandInputs turns the matched files into the list of faces to convert.
+// With --all-faces (and no explicit "#N"), collections expand into one input
+// per face, named after the face's name-table entry.
+func expandInputs(files []string, cfg Config, hasIndex bool) ([]FontInput, error) {
+	var inputs []FontInput
+	for _, path := range files {
+		baseName := filepath.Base(path)
+		nameNoExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+
+		if !cfg.AllFaces || hasIndex {
+			inputs = append(inputs, FontInput{Path: path, FaceIndex: cfg.FaceIndex, Name: faceName(path, nameNoExt, cfg.FaceIndex)})
+			continue
+		}
+
+		names, err := converter.FaceNames(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", baseName, err)
+		}
+		if len(names) == 1 {
+			inputs = append(inputs, FontInput{Path: path, Name: nameNoExt})
+			continue
+		}
+		for i, name := range names {
+			if name == "" {
+				name = fmt.Sprintf("%s-%d", nameNoExt, i)
+			}
+			inputs = append(inputs, FontInput{Path: path, FaceIndex: i, Name: name})
+		}
+	}
+	return inputs, nil
+}
+
+// faceName names the outputs of one face picked from a collection after
+// the face, as --all-faces does, so that faces of the same file don't
+// overwrite each other. The first face (or a plain font) keeps the file name.
+func faceName(path, nameNoExt string, index int) string {
+	if index == 0 {
+		return nameNoExt
+	}
+	if names, err := converter.FaceNames(path); err == nil && index < len(names) && names[index] != "" {
+		return names[index]
+	}
+	return fmt.Sprintf("%s-%d", nameNoExt, index) // Unreadable fonts fail in their jobs
+}
+
+// watchInterval is how often --watch polls its inputs.
+const watchInterval = time.Second
+
+// fileStamp is what --watch compares to notice that a file changed.
+type fileStamp struct {
+	size    int64
+	modTime int64 // Unix nanoseconds
+}
+
+// stampFiles adds the stamp of every file in paths to stamps; files that
+// don't exist (or disappear while it runs) are left out.
+func stampFiles(stamps map[string]fileStamp, paths ...string) {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			stamps[path] = fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}
+		}
+	}
+}
+
+// stampInputs returns the stamps of every font the pattern matches and of
+// the settings and characters files, and the fonts in order.
+func stampInputs(pattern string, cfg Config) (map[string]fileStamp, []string, error) {
+	files, err := findFonts(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	stamps := make(map[string]fileStamp, len(files)+2)
+	stampFiles(stamps, files...)
+	fonts := slices.DeleteFunc(slices.Sorted(maps.Keys(stamps)), func(path string) bool {
+		return path == cfg.ConfigFile || path == cfg.CharsFile || path == cfg.SheetMapFile
+	})
+	for _, path := range []string{cfg.ConfigFile, cfg.CharsFile, cfg.SheetMapFile} {
+		if path != "" {
+			stampFiles(stamps, path)
+		}
+	}
+	return stamps, fonts, nil
+}
+
+// describeChanges lists the files added, changed and removed between two
+// polls, e.g. "added A.ttf; removed B.ttf".
+func describeChanges(before, after map[string]fileStamp) string {
+	var added, changed, removed []string
+	for _, path := range slices.Sorted(maps.Keys(after)) {
+		if old, ok := before[path]; !ok {
+			added = append(added, filepath.Base(path))
+		} else if old != after[path] {
+			changed = append(changed, filepath.Base(path))
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[path]; !ok {
+			removed = append(removed, filepath.Base(path))
+		}
+	}
+	var parts []string
+	for _, c := range []struct {
+		verb  string
+		names []string
+	}{{"added", added}, {"changed", changed}, {"removed", removed}} {
+		if len(c.names) > 0 {
+			parts = append(parts, c.verb+" "+strings.Join(c.names, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// watch converts the fonts, then polls them, the --config file and the
+// --chars-file and converts again whenever they change, until ctx is
+// cancelled. A change is picked up once the files stay the same for a whole
+// poll (saving may take several writes), and the manifest skips every job
+// whose font and settings didn't change. Settings are read again on every
+// change; if they are invalid, the previous ones are kept. Fonts that
+// disappear are dropped from the batch and from the manifest; their outputs
+// are left as they are.
+func watch(ctx context.Context, cfg Config) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	pattern, hasIndex := fontPattern(&cfg)
+	var built, last map[string]fileStamp
+	var builtFonts []string
+	for round := 0; ; {
+		stamps, fonts, err := stampInputs(pattern, cfg)
+		switch {
+		case err != nil:
+			fmt.Printf("Glob error: %v\n", err)
+		case round == 0 || (!maps.Equal(stamps, built) && maps.Equal(stamps, last)):
+			// Redraw the dashboard from the top of the screen.
+			fmt.Print("\033[H\033[2J")
+			changes := describeChanges(built, stamps)
+			if round > 0 {
+				// The settings may name other fonts or files to watch.
+				if next, err := reloadConfig(); err != nil {
+					changes += fmt.Sprintf("\nError: %v (keeping the previous settings)", err)
+				} else {
+					cfg = next
+					pattern, hasIndex = fontPattern(&cfg)
+					if stamps, fonts, err = stampInputs(pattern, cfg); err != nil {
+						fmt.Printf("Glob error: %v\n", err)
+						break
+					}
+				}
+			}
+			fmt.Printf("Watching %s (%d fonts, Ctrl+C to stop), run %d at %s\n", cfg.FontPattern, len(fonts), round+1, time.Now().Format("15:04:05"))
+			if round > 0 {
+				fmt.Printf("Inputs %s.\n", changes)
+			}
+			fmt.Println()
+
+			if dropped := slices.DeleteFunc(builtFonts, func(path string) bool { return slices.Contains(fonts, path) }); len(dropped) > 0 {
+				if err := forgetFonts(cfg.OutputDir, dropped); err != nil {
+					fmt.Printf("WARN %v\n", err)
+				}
+			}
+			inputs, err := expandInputs(fonts, cfg, hasIndex)
+			switch {
+			case err != nil:
+				fmt.Printf("Error: %v\n", err)
+			case len(inputs) == 0:
+				fmt.Println("No fonts found yet; waiting for some to appear.")
+			default:
+				processBatch(ctx, inputs, cfg)
+			}
+			built, builtFonts = stamps, fonts
+			round++
+		}
+		last = stamps
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopped watching.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// forgetFonts removes the manifest jobs of fonts that left the batch, so
+// that they are rebuilt if the fonts come back.
+func forgetFonts(dir string, paths []string) error {
+	manifest, err := converter.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	for key := range manifest.Jobs {
+		if slices.Contains(paths, jobFont(key)) {
+			manifest.Forget(key)
+		}
+	}
+	return manifest.Write(dir)
+}
+
+// jobKey returns the manifest key of a job, "path#face@size".
+func jobKey(input FontInput, size float64) string {
+	return fmt.Sprintf("%s#%d@%g", filepath.ToSlash(input.Path), input.FaceIndex, size)
+}
+
+// jobFont returns the font path of a manifest key (see jobKey).
+func jobFont(key string) string {
+	if i := strings.LastIndex(key, "@"); i >= 0 {
+		key = key[:i]
+	}
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		key = key[:i]
+	}
+	return filepath.FromSlash(key)
+}
+
+// batchFont is a font shared by its jobs: the first one to run parses it,
+// and the last one releases it, so each font is parsed once and only the
+// fonts being worked on (about one per worker) stay in memory.
+type batchFont struct {
+	input    FontInput
+	baseName string
+	pending  atomic.Int32 // Jobs not finished yet
+
+	// Name table and permission, read for every font up front
+	info    converter.FaceInfo
+	infoErr error
+
+	once   sync.Once
+	opened bool // Some job needed it (the others may all be up to date)
+	font   *converter.Font
+	err    error
+}
+
+// acquire parses the font on first use.
+func (b *batchFont) acquire(cfg Config) (*converter.Font, error) {
+	b.once.Do(func() {
+		b.opened = true
+		b.font, b.err = converter.OpenFont(b.input.Path, jobOptions(cfg, b.input, 0))
+	})
+	return b.font, b.err
+}
+
+// release drops the parsed font after its last job.
+func (b *batchFont) release() {
+	if b.pending.Add(-1) == 0 {
+		b.font = nil
+	}
+}
+
+// batchJob is one font at one size (or at the size --fit picks for it).
+type batchJob struct {
+	font *batchFont
+	size float64 // 0 with --fit
+
+	// Manifest key and hashes, recorded when the job succeeds
+	key         string
+	fontHash    string
+	optionsHash string
+}
+
+// jobResult is what a job reports; results are collected in job order so
+// the summary doesn't depend on which worker finished first.
+type jobResult struct {
+	ok       bool
+	size     float64 // Size rendered (the fitted one with --fit)
+	failure  string
+	err      error // Why the job failed
+	fitted   string
+	warnings []string
+	stats    converter.Stats
+}
+
+// jobEvent tells the dashboard what a worker is doing.
+type jobEvent struct {
+	worker int
+	msg    string
+	done   bool // The worker finished a job
+}
+
+// processBatch converts the inputs and prints the summary. It reports
+// whether every job succeeded (or was up to date).
+func processBatch(ctx context.Context, inputs []FontInput, cfg Config) bool {
+	successCount := 0
+	var permissions, fitted, skipped []string
+	warnings := append([]string(nil), cfg.Notices...)
+	failures := map[string][]string{} // By failureKinds title
+	var dedup converter.Stats
 
 	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
-		fmt.Print("\033[?25h")
 		fmt.Printf("Error: Failed to create output directory: %v\n", err)
-		os.Exit(1)
+		return false
 	}
 
 	start := time.Now()
 
-	for _, fontPath := range files {
-		baseName := filepath.Base(fontPath)
-		nameNoExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	// Incremental builds: the manifest in the output directory records the
+	// font and options every job was built from and the files it wrote.
+	manifest, err := converter.ReadManifest(cfg.OutputDir)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("WARN %v (rebuilding everything)", err))
+		manifest = &converter.Manifest{Jobs: map[string]converter.ManifestEntry{}}
+	}
+	fontHashes := map[string]string{} // By path; collections share one
 
-		for _, size := range cfg.Sizes {
-			currentJob++
-			outPrefix := filepath.Join(cfg.OutputDir, fmt.Sprintf("%s-%d", nameNoExt, size))
+	// Jobs must not share an output name, or they would overwrite each
+	// other's files. With --fit the size isn't known yet, so it doesn't count.
+	instanceName := converter.VariationName(cfg.NamedInstance, cfg.Axes)
+	owners := outputOwners{}
+	var collisions []string
 
-			msg := fmt.Sprintf("Processing %s @ %dpx (pad:%d, hint:%s)...", baseName, size, cfg.Padding, cfg.Hinting)
-			updateUI(currentJob, totalJobs, msg)
+	// One job per font and size, in input order, leaving out the jobs
+	// whose font, options and outputs haven't changed (unless --force).
+	fonts := make([]*batchFont, len(inputs))
+	var jobs []batchJob
+	for i, input := range inputs {
+		baseName := filepath.Base(input.Path)
+		if input.FaceIndex > 0 {
+			baseName = fmt.Sprintf("%s#%d", baseName, input.FaceIndex)
+		}
+		fonts[i] = &batchFont{input: input, baseName: baseName}
 
-			// Pass Hinting
-			err := converter.Generate(fontPath, size, cfg.Chars, outPrefix, cfg.Format, cfg.Padding, cfg.Hinting)
+		fontHash, ok := fontHashes[input.Path]
+		if !ok {
+			fontHash, _ = converter.HashFile(input.Path) // Unreadable fonts fail in their jobs
+			fontHashes[input.Path] = fontHash
+		}
 
-			if err != nil {
-				errMsg := fmt.Sprintf("FAIL %s @ %dpx: %v", baseName, size, err)
-				updateUI(currentJob, totalJobs, errMsg)
-				failures = append(failures, errMsg)
-			} else {
-				successCount++
+		// The name table names the outputs, and the permission of every font
+		// is reported, even if all its jobs are up to date.
+		info, infoErr := converter.ReadFaceInfo(input.Path, input.FaceIndex) // Unreadable fonts fail in their jobs
+		fonts[i].info, fonts[i].infoErr = info, infoErr
+
+		// With a texture budget, the size is searched per font.
+		sizes := cfg.Sizes
+		if cfg.FitWidth > 0 {
+			sizes = []float64{0}
+		}
+		for _, size := range sizes {
+			label, where := sizeLabel(size, cfg.SizeMode), sizeDisplay(size, cfg.SizeMode)
+			if cfg.FitWidth > 0 {
+				label, where = "{size}", fmt.Sprintf("fit %dx%d", cfg.FitWidth, cfg.FitHeight)
+			}
+			name := outputName(cfg.NameTemplate, input, info, instanceName, label)
+			owner := fmt.Sprintf("%s @ %s", baseName, where)
+			if other, ok := owners.claim(name, owner); !ok {
+				collisions = append(collisions, fmt.Sprintf("%s: %s and %s", name, other, owner))
+			}
+
+			job := batchJob{
+				font:        fonts[i],
+				size:        size,
+				key:         jobKey(input, size),
+				fontHash:    fontHash,
+				optionsHash: converter.HashOptions(jobOptions(cfg, input, size), cfg.NameTemplate, input.Name, Version),
+			}
+			if !cfg.Force {
+				if e, ok := manifest.UpToDate(cfg.OutputDir, job.key, job.fontHash, job.optionsHash); ok {
+					skipped = append(skipped, fmt.Sprintf("%s @ %s", baseName, sizeDisplay(e.Size, cfg.SizeMode)))
+					continue
+				}
 			}
+			jobs = append(jobs, job)
+			fonts[i].pending.Add(1)
+		}
+	}
+	if len(collisions) > 0 {
+		fmt.Println("Error: several jobs would write the same files (use a --name template that tells them apart, e.g. with {file} or {style}):")
+		for _, c := range collisions {
+			fmt.Printf("  -> %s\n", c)
+		}
+		return false
+	}
+	totalJobs := len(jobs)
+
+	// UI Setup: a progress bar and one line per worker.
+	workers := max(1, min(cfg.Jobs, totalJobs))
+	logBuffer = make([]string, workers)
+	fmt.Print(strings.Repeat("\n", workers+1))
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h")
+
+	// Run the jobs on the worker pool; cancelling ctx stops them and reports what was done.
+	results := make([]jobResult, totalJobs)
+	queue := make(chan int)
+	events := make(chan jobEvent)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = runJob(ctx, cfg, jobs[i], func(msg string) {
+					events <- jobEvent{worker: worker, msg: msg}
+				})
+				msg := "idle"
+				if !results[i].ok {
+					msg = results[i].failure
+				}
+				events <- jobEvent{worker: worker, msg: msg, done: true}
+			}
+		}(w)
+	}
+	go func() {
+		for i := range jobs {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+		close(events)
+	}()
+
+	currentJob := 0
+	for ev := range events {
+		if ev.done {
+			currentJob++
+		}
+		logBuffer[ev.worker] = fmt.Sprintf("[%d] %s", ev.worker+1, ev.msg)
+		updateUI(currentJob, totalJobs)
+	}
+
+	// Embedding permission, per font (warnings only for the fonts converted).
+	for _, b := range fonts {
+		if b.infoErr != nil {
+			permissions = append(permissions, fmt.Sprintf("%s: unknown (%v)", b.baseName, b.infoErr))
+			continue
+		}
+		permissions = append(permissions, fmt.Sprintf("%s: %s (fsType=0x%04x)", b.baseName, b.info.Embedding, b.info.FsType))
+		switch {
+		case !b.opened || b.err != nil:
+		case b.info.Embedding == converter.EmbeddingPreviewPrint:
+			warnings = append(warnings, fmt.Sprintf("WARN %s: license allows preview & print embedding only", b.baseName))
+		case b.info.Embedding == converter.EmbeddingRestricted && cfg.AllowRestricted:
+			warnings = append(warnings, fmt.Sprintf("WARN %s: restricted license, converted because of --allow-restricted", b.baseName))
+		}
+	}
+
+	for i, res := range results {
+		if res.fitted != "" {
+			fitted = append(fitted, res.fitted)
+		}
+		warnings = append(warnings, res.warnings...)
+		if !res.ok {
+			kind := failureKind(res.err)
+			failures[kind] = append(failures[kind], res.failure)
+			manifest.Forget(jobs[i].key)
+			continue
+		}
+		job := jobs[i]
+		if err := manifest.Record(cfg.OutputDir, job.key, job.fontHash, job.optionsHash, res.size, res.stats.Files); err != nil {
+			warnings = append(warnings, fmt.Sprintf("WARN manifest: %v", err))
+		}
+		successCount++
+		dedup.Glyphs += res.stats.Glyphs
+		dedup.Bitmaps += res.stats.Bitmaps
+		dedup.SavedPixels += res.stats.SavedPixels
+	}
+
+	fmt.Printf("\033[%dA\033[J", len(logBuffer)+1)
+	if err := manifest.Write(cfg.OutputDir); err != nil {
+		warnings = append(warnings, fmt.Sprintf("WARN %v", err))
+	}
+
+	fmt.Printf("Done in %v. %d/%d successful, %d skipped (up to date).\n", time.Since(start).Round(time.Millisecond), successCount, totalJobs, len(skipped))
+
+	if len(permissions) > 0 {
+		fmt.Println("\n=== EMBEDDING PERMISSIONS ===")
+		for _, msg := range permissions {
+			fmt.Printf(" -> %s\n", msg)
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Println("\n=== SKIPPED (UP TO DATE, --force rebuilds) ===")
+		for _, msg := range skipped {
+			fmt.Printf(" -> %s\n", msg)
+		}
+	}
+
+	if dedup.Bitmaps < dedup.Glyphs {
+		fmt.Println("\n=== DEDUPLICATION ===")
+		fmt.Printf(" -> %d glyphs packed as %d bitmaps (%d shared, %d px saved)\n",
+			dedup.Glyphs, dedup.Bitmaps, dedup.Glyphs-dedup.Bitmaps, dedup.SavedPixels)
+	}
+
+	if len(fitted) > 0 {
+		fmt.Println("\n=== FITTED SIZES ===")
+		for _, msg := range fitted {
+			fmt.Printf(" -> %s\n", msg)
 		}
 	}
 
-	fmt.Print("\033[6A\033[J")
-	fmt.Printf("Done in %v. %d/%d successful.\n", time.Since(start).Round(time.Millisecond), successCount, totalJobs)
+	if len(warnings) > 0 {
+		fmt.Println("\n=== WARNINGS ===")
+		for _, msg := range warnings {
+			fmt.Printf(" -> %s\n", msg)
+		}
+	}
 
 	if len(failures) > 0 {
 		fmt.Println("\n=== FAILURE REPORT ===")
-		for _, msg := range failures {
-			fmt.Printf(" -> %s\n", msg)
+		for _, kind := range failureKinds {
+			if len(failures[kind.title]) == 0 {
+				continue
+			}
+			fmt.Printf("%s (%d):\n", kind.title, len(failures[kind.title]))
+			for _, msg := range failures[kind.title] {
+				fmt.Printf(" -> %s\n", msg)
+			}
 		}
 		fmt.Println("======================")
-		os.Exit(1)
+		return false
+	}
+	return true
+}
+
+// failureKinds groups the failure report, in this order; the last one
+// catches everything else.
+var failureKinds = []struct {
+	title string
+	is    func(error) bool
+}{
+	{"Unreadable fonts", func(err error) bool { return errors.Is(err, converter.ErrFontUnreadable) }},
+	{"Invalid fonts", func(err error) bool { return errors.Is(err, converter.ErrFontInvalid) }},
+	{"Restricted licenses", func(err error) bool { return errors.Is(err, converter.ErrEmbeddingRestricted) }},
+	{"Invalid options", func(err error) bool { return errors.Is(err, converter.ErrInvalidOptions) }},
+	{"Missing glyphs", func(err error) bool {
+		var missing *converter.MissingGlyphsError
+		return errors.As(err, &missing)
+	}},
+	{"Texture budget exceeded", func(err error) bool { return errors.Is(err, converter.ErrTextureTooLarge) }},
+	{"Interrupted", func(err error) bool { return errors.Is(err, context.Canceled) }},
+	{"Other errors", func(error) bool { return true }},
+}
+
+// failureKind returns the failureKinds title for err.
+func failureKind(err error) string {
+	for _, kind := range failureKinds {
+		if kind.is(err) {
+			return kind.title
+		}
+	}
+	return ""
+}
+
+// runJob converts one font at one size, reporting progress through status.
+// Once ctx is cancelled, it stops at the next glyph and fails.
+func runJob(ctx context.Context, cfg Config, job batchJob, status func(string)) jobResult {
+	var res jobResult
+	b := job.font
+	defer b.release()
+
+	font, err := b.acquire(cfg)
+	size := job.size
+	if err == nil {
+		err = ctx.Err() // Jobs still queued when interrupted are not started
+	}
+	if err != nil {
+		where := sizeDisplay(size, cfg.SizeMode)
+		if cfg.FitWidth > 0 {
+			where = fmt.Sprintf("fit %dx%d", cfg.FitWidth, cfg.FitHeight)
+		}
+		res.failure, res.err = fmt.Sprintf("FAIL %s @ %s: %v", b.baseName, where, err), err
+		return res
+	}
+
+	if cfg.FitWidth > 0 {
+		status(fmt.Sprintf("Fitting %s into %dx%d...", b.baseName, cfg.FitWidth, cfg.FitHeight))
+		progress := progressStatus(status, func(size float64) string {
+			return fmt.Sprintf("Fitting %s into %dx%d @ %s", b.baseName, cfg.FitWidth, cfg.FitHeight, sizeDisplay(size, cfg.SizeMode))
+		})
+		if size, err = font.FitSizeContext(ctx, progress); err != nil {
+			res.failure, res.err = fmt.Sprintf("FAIL %s @ fit %dx%d: %v", b.baseName, cfg.FitWidth, cfg.FitHeight, err), err
+			return res
+		}
+		res.fitted = fmt.Sprintf("%s: %s fits %dx%d", b.baseName, sizeDisplay(size, cfg.SizeMode), cfg.FitWidth, cfg.FitHeight)
 	}
+
+	// Variable font instances are tagged onto the file name, e.g. "Font-Bold-32".
+	instanceName := converter.VariationName(cfg.NamedInstance, cfg.Axes)
+	label := sizeLabel(size, cfg.SizeMode)
+	outPrefix := filepath.Join(cfg.OutputDir, outputName(cfg.NameTemplate, b.input, b.info, instanceName, label))
+
+	progress := progressStatus(status, func(size float64) string {
+		return fmt.Sprintf("Processing %s @ %s (hint:%s)", b.baseName, sizeDisplay(size, cfg.SizeMode), cfg.Hinting)
+	})
+	stats, err := font.GenerateContext(ctx, outPrefix, size, progress)
+	if err != nil {
+		res.failure, res.err = fmt.Sprintf("FAIL %s @ %s: %v", b.baseName, sizeDisplay(size, cfg.SizeMode), err), err
+		return res
+	}
+	res.ok, res.size, res.stats = true, size, stats
+	if stats.Unmapped != "" {
+		res.warnings = append(res.warnings, fmt.Sprintf("%s @ %s: %q not in charset %s (left out)", b.baseName, sizeDisplay(size, cfg.SizeMode), stats.Unmapped, cfg.Charset))
+	}
+	if stats.Missing != "" {
+		res.warnings = append(res.warnings, fmt.Sprintf("%s @ %s: %q not in the font (left out)", b.baseName, sizeDisplay(size, cfg.SizeMode), stats.Missing))
+	}
+	if stats.Overflow != "" {
+		res.warnings = append(res.warnings, fmt.Sprintf("%s @ %s: glyphs %q overflow the fixed cell (clipped)", b.baseName, sizeDisplay(size, cfg.SizeMode), stats.Overflow))
+	}
+	return res
+}
+
+// stageVerbs names the converter stages on the dashboard.
+var stageVerbs = map[converter.Stage]string{
+	converter.StageRasterise: "rasterising",
+	converter.StagePack:      "packing",
+	converter.StageWrite:     "writing",
 }
 
-func validateInputs(f, s, c, o, t string, p int, h string) (Config, error) {
-	if f == "" || s == "" || c == "" {
+// progressStatus turns converter progress into worker lines ("<label>:
+// rasterising 40%"), passing them to status only when they change.
+func progressStatus(status func(string), label func(size float64) string) converter.ProgressFunc {
+	last := ""
+	return func(p converter.Progress) {
+		msg := fmt.Sprintf("%s: %s %d%%", label(p.Size), stageVerbs[p.Stage], p.Done*100/max(1, p.Total))
+		if msg != last {
+			last = msg
+			status(msg)
+		}
+	}
+}
+
+// jobOptions builds the converter options for one font and size.
+func jobOptions(cfg Config, input FontInput, size float64) converter.Options {
+	return converter.Options{
+		Size:      size,
+		Chars:     cfg.Chars,
+		Format:    cfg.Format,
+		Padding:   cfg.Padding,
+		Spacing:   cfg.Spacing,
+		Hinting:   cfg.Hinting,
+		FaceIndex: input.FaceIndex,
+
+		NamedInstance: cfg.NamedInstance,
+		Axes:          cfg.Axes,
+
+		AllowRestricted: cfg.AllowRestricted,
+		EmitMeta:        cfg.EmitMeta,
+
+		Supersample:       cfg.Supersample,
+		SupersampleFilter: cfg.SupersampleFilter,
+		Antialias:         cfg.Antialias,
+		Threshold:         cfg.Threshold,
+		LCD:               cfg.LCD,
+		Gamma:             cfg.Gamma,
+		Contrast:          cfg.Contrast,
+		Alpha:             cfg.Alpha,
+		Embolden:          cfg.Embolden,
+		Oblique:           cfg.Oblique,
+		DPI:               cfg.DPI,
+		SizeMode:          cfg.SizeMode,
+		FitWidth:          cfg.FitWidth,
+		FitHeight:         cfg.FitHeight,
+		Monospace:         cfg.Monospace,
+		CellWidth:         cfg.CellWidth,
+		Sheet:             cfg.Sheet,
+		SheetListing:      cfg.SheetListing,
+		SheetMap:          cfg.SheetMap,
+		Charset:           cfg.Charset,
+	}
+}
+
+// parseSides parses "N" (every side) or n comma-separated non-negative
+// pixel counts, in BMFont order.
+func parseSides(s string, n int) ([]int, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 1 && len(parts) != n {
+		return nil, fmt.Errorf("%q: want 1 or %d values", s, n)
+	}
+	values := make([]int, n)
+	for i := range values {
+		v, err := strconv.Atoi(strings.TrimSpace(parts[i%len(parts)]))
+		if err != nil {
+			return nil, err
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("%d cannot be negative", v)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseFit parses a "WxH" texture budget ("" means none).
+func parseFit(s string) (w, h int, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	if _, err := fmt.Sscanf(strings.ToLower(s), "%dx%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid texture budget %q (use WxH, e.g. 256x256)", s)
+	}
+	return w, h, nil
+}
+
+// outputName expands the output name template for one job.
+// {file} is the font file (or collection face) name, {family}, {style} and
+// {full} come from the name table, falling back to the file name. Variable
+// font instances replace {style} and are appended to {file}.
+func outputName(template string, input FontInput, info converter.FaceInfo, instance string, size string) string {
+	file, family, style, full := input.Name, info.Family, info.Style, info.FullName
+	if family == "" {
+		family = input.Name
+	}
+	if instance != "" {
+		file += "-" + converter.SanitizeName(instance)
+		style = instance
+		full = strings.TrimSpace(family + " " + instance)
+	}
+	if full == "" {
+		full = strings.TrimSpace(family + " " + style)
+	}
+
+	values := map[string]string{
+		"file":   file,
+		"family": converter.SanitizeName(family),
+		"style":  converter.SanitizeName(style),
+		"full":   converter.SanitizeName(full),
+		"size":   size,
+	}
+
+	// A placeholder that expands to nothing takes one separator next to it
+	// along, so an empty style gives "Go-32" rather than "Go--32". Separators
+	// in the template or the names themselves are kept as they are.
+	var b strings.Builder
+	dropSep := false // The last placeholder was empty at a separator
+	literal := func(lit string) {
+		if lit == "" {
+			return
+		}
+		if dropSep && isNameSeparator(lit[0]) {
+			lit = lit[1:]
+		}
+		b.WriteString(lit)
+		dropSep = false
+	}
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			literal(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}') + start
+		value, ok := "", false
+		if end > start {
+			value, ok = values[rest[start+1:end]]
+		}
+		if !ok { // Braces that aren't a placeholder are literal text
+			literal(rest[:start+1])
+			rest = rest[start+1:]
+			continue
+		}
+		literal(rest[:start])
+		if value == "" {
+			s := b.String()
+			dropSep = dropSep || s == "" || isNameSeparator(s[len(s)-1])
+		} else {
+			b.WriteString(value)
+			dropSep = false
+		}
+		rest = rest[end+1:]
+	}
+	name := b.String()
+	if dropSep && name != "" && isNameSeparator(name[len(name)-1]) {
+		name = name[:len(name)-1]
+	}
+	return name
+}
+
+// outputOwners maps output names, lowercased, to the job writing them.
+// Names are compared ignoring case, since macOS and Windows would have
+// "Go-32" and "go-32" overwrite each other.
+type outputOwners map[string]string
+
+// claim records that owner writes name. If another job already does, it
+// returns that job and false.
+func (o outputOwners) claim(name, owner string) (string, bool) {
+	key := strings.ToLower(name)
+	if other, ok := o[key]; ok {
+		return other, false
+	}
+	o[key] = owner
+	return owner, true
+}
+
+// isNameSeparator reports whether c separates the parts of an output name.
+func isNameSeparator(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || c == ' '
+}
+
+// sizeLabel formats a size for output names: "12", "10.5", or "32px" when
+// the size is a pixel height.
+func sizeLabel(size float64, mode string) string {
+	label := strconv.FormatFloat(size, 'f', -1, 64)
+	if mode != converter.SizePoints {
+		label += "px"
+	}
+	return label
+}
+
+// sizeDisplay formats a size for progress messages, e.g. "12pt" or "32px line".
+func sizeDisplay(size float64, mode string) string {
+	if mode == converter.SizePoints {
+		return strconv.FormatFloat(size, 'f', -1, 64) + "pt"
+	}
+	return sizeLabel(size, mode) + " " + mode
+}
+
+func validateInputs(f, s, c, o, t, p, sp, h string) (Config, error) {
+	// Characters may be left out when --sheet picks them.
+	if f == "" {
 		return Config{}, fmt.Errorf("missing arguments")
 	}
 
@@ -156,8 +1373,13 @@ func validateInputs(f, s, c, o, t string, p int, h string) (Config, error) {
 		return Config{}, fmt.Errorf("invalid type: %s (must be 'png' or 'bmp')", t)
 	}
 
-	if p < 0 {
-		return Config{}, fmt.Errorf("padding cannot be negative")
+	padding, err := parseSides(p, 4)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid padding: %w", err)
+	}
+	spacing, err := parseSides(sp, 2)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid spacing: %w", err)
 	}
 
 	h = strings.ToLower(h)
@@ -165,29 +1387,36 @@ func validateInputs(f, s, c, o, t string, p int, h string) (Config, error) {
 		return Config{}, fmt.Errorf("invalid hinting: %s (use 'none', 'vertical', 'full')", h)
 	}
 
-	var sizeInts []int
-	for _, pStr := range strings.Split(s, ",") {
-		val, err := strconv.Atoi(strings.TrimSpace(pStr))
-		if err != nil {
-			return Config{}, err
+	// Sizes may be left out when --fit chooses them.
+	var sizes []float64
+	if s != "" {
+		for _, pStr := range strings.Split(s, ",") {
+			val, err := strconv.ParseFloat(strings.TrimSpace(pStr), 64)
+			if err != nil {
+				return Config{}, err
+			}
+			if val <= 0 {
+				return Config{}, fmt.Errorf("invalid size: %v (must be positive)", val)
+			}
+			sizes = append(sizes, val)
 		}
-		sizeInts = append(sizeInts, val)
+		sort.Float64s(sizes)
 	}
-	sort.Ints(sizeInts)
 
 	return Config{
 		FontPattern: f,
-		Sizes:       sizeInts,
+		Sizes:       sizes,
 		Chars:       c,
 		OutputDir:   o,
 		Format:      t,
-		Padding:     p,
+		Padding:     [4]int(padding),
+		Spacing:     [2]int(spacing),
 		Hinting:     h, // Set hinting
 	}, nil
 }
 
-func updateUI(current, total int, msg string) {
-	logBuffer = append(logBuffer[1:], msg)
+// updateUI redraws the progress bar and the worker lines.
+func updateUI(current, total int) {
 	percent := 0
 	if total > 0 {
 		percent = (current * 100) / total
@@ -197,7 +1426,7 @@ func updateUI(current, total int, msg string) {
 	filled := (percent * width) / 100
 	bar := fmt.Sprintf("[%s%s]", strings.Repeat("=", filled), strings.Repeat(" ", width-filled))
 
-	fmt.Print("\033[6A")
+	fmt.Printf("\033[%dA", len(logBuffer)+1)
 	fmt.Printf("%s %3d%% (%d/%d)\033[K\n", bar, percent, current, total)
 	for _, line := range logBuffer {
 		if len(line) > 75 {
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ttf2bmp/converter"
+)
+
+func TestOutputName(t *testing.T) {
+	input := FontInput{Path: "fonts/Go-Regular.ttf", Name: "Go-Regular"}
+	regular := converter.FaceInfo{Family: "Go"}
+	bold := converter.FaceInfo{Family: "Go Mono", Style: "Bold"}
+	tests := []struct {
+		template string
+		info     converter.FaceInfo
+		instance string
+		want     string
+	}{
+		{"{file}-{size}", regular, "", "Go-Regular-32"},
+		{"{family}-{style}-{size}", bold, "", "GoMono-Bold-32"},
+		{"{full}_{size}", bold, "", "GoMonoBold_32"},
+
+		// An empty placeholder takes one separator next to it along.
+		{"{family}-{style}-{size}", regular, "", "Go-32"},
+		{"{family}_{style}.{size}", regular, "", "Go_32"},
+		{"{style}-{size}", regular, "", "32"},
+		{"{style}-{style}-{size}", regular, "", "32"},
+		{"{family}-{style}", regular, "", "Go"},
+		{"{family}{style}{size}", regular, "", "Go32"},
+		{"{family}-{size}", converter.FaceInfo{}, "", "Go-Regular-32"},
+
+		// Separators in the template are otherwise kept as they are.
+		{"{family}--{size}", regular, "", "Go--32"},
+		{"{family}-{style}--{size}", regular, "", "Go--32"},
+		{" {family} ", bold, "", " GoMono "},
+
+		// Braces that aren't a placeholder are literal text.
+		{"{a}-{size}", regular, "", "{a}-32"},
+		{"{family}-{size", regular, "", "Go-{size"},
+		{"}{family}{", regular, "", "}Go{"},
+
+		// Instances are appended to {file} and replace {style}.
+		{"{file}-{style}-{size}", regular, "Bold Condensed", "Go-Regular-BoldCondensed-BoldCondensed-32"},
+		{"{full}-{size}", regular, "wght700", "Gowght700-32"},
+	}
+	for _, tt := range tests {
+		if got := outputName(tt.template, input, tt.info, tt.instance, "32"); got != tt.want {
+			t.Errorf("outputName(%q, %+v, %q) = %q, want %q", tt.template, tt.info, tt.instance, got, tt.want)
+		}
+	}
+}
+
+func TestOutputOwners(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string // "name: first job and second job" per clash
+	}{
+		{[]string{"Go-32", "Go-24", "GoMono-32"}, nil},
+		{[]string{"Go-32", "Go-32"}, []string{"Go-32: job0 and job1"}},
+		{[]string{"Go-32", "go-32"}, []string{"go-32: job0 and job1"}},
+		{[]string{"Go-Bold-32", "Go-24", "GO-BOLD-32", "go-bold-32"}, []string{"GO-BOLD-32: job0 and job2", "go-bold-32: job0 and job3"}},
+		{[]string{"Straße-32", "STRASSE-32"}, nil}, // Only simple case folding
+	}
+	for _, tt := range tests {
+		owners := outputOwners{}
+		var got []string
+		for i, name := range tt.names {
+			owner := fmt.Sprint("job", i)
+			if other, ok := owners.claim(name, owner); !ok {
+				got = append(got, name+": "+other+" and "+owner)
+			}
+		}
+		if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+			t.Errorf("%q: clashes %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestSplitFaceIndex(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Font#1", "C#Mono.ttf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "Sharp#2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pattern string
+		path    string
+		index   int
+		ok      bool
+	}{
+		{"fonts.ttc#2", "fonts.ttc", 2, true},
+		{"*.ttc#0", "*.ttc", 0, true},
+		{"fonts.ttc#007", "fonts.ttc", 7, true},
+		{"Font#1#2", "Font#1", 2, true},
+		{"Sharp#2/fonts.ttc#3", "Sharp#2/fonts.ttc", 3, true},
+
+		// Real file names containing '#' are left alone.
+		{"Font#1", "Font#1", 0, false},
+		{"C#Mono.ttf", "C#Mono.ttf", 0, false},
+		{"Sharp#2", "Sharp#2", 0, false},
+		{"Sharp#2/fonts.ttc", "Sharp#2/fonts.ttc", 0, false},
+
+		// Anything but trailing digits isn't an index.
+		{"fonts.ttc", "fonts.ttc", 0, false},
+		{"fonts.ttc#", "fonts.ttc#", 0, false},
+		{"fonts.ttc#x1", "fonts.ttc#x1", 0, false},
+		{"fonts.ttc#-1", "fonts.ttc#-1", 0, false},
+		{"fonts.ttc#99999999999999999999", "fonts.ttc#99999999999999999999", 0, false},
+	}
+	for _, tt := range tests {
+		pattern, want := filepath.Join(dir, tt.pattern), filepath.Join(dir, tt.path)
+		path, index, ok := splitFaceIndex(pattern)
+		if path != want || index != tt.index || ok != tt.ok {
+			t.Errorf("splitFaceIndex(%q) = %q, %d, %v; want %q, %d, %v", tt.pattern, path, index, ok, want, tt.index, tt.ok)
+		}
+	}
+}
+
+// parseWithConfig parses args, then applies a settings file holding config.
+func parseWithConfig(t *testing.T, args []string, config string) (*flagValues, *flag.FlagSet, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ttf2bmp.conf")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("ttf2bmp", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	v := defineFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return v, fs, applyConfigFile(fs, path)
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	config := strings.Join([]string{
+		"# UI fonts",
+		"",
+		"  sizes = 12  ",
+		`chars = "A\"B\né\t"`,
+		`name = " {family} "`,
+		`out = my "dir"`,
+		`fonts = "`,
+		"--hinting = none",
+		"force",
+		"emit-meta = false",
+		"all-faces = true",
+		"watch = FALSE",
+		"p = 3",
+		"jobs=4",
+	}, "\n")
+
+	// The command line wins over the file, whether either uses the short or
+	// the long name.
+	v, _, err := parseWithConfig(t, []string{"-s", "24", "--spacing", "5", "--emit-meta"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		setting   string
+		got, want any
+	}{
+		{"sizes", v.sizesFlag, "24"},
+		{"chars", v.charsFlag, "A\"B\né\t"},
+		{"name", v.nameFlag, " {family} "},
+		{"out", v.outDir, `my "dir"`},
+		{"fonts", v.fontsFlag, `"`},
+		{"hinting", v.hintingFlag, "none"},
+		{"force", v.forceFlag, true},
+		{"emit-meta", v.emitMetaFlag, true},
+		{"all-faces", v.allFacesFlag, true},
+		{"watch", v.watchFlag, false},
+		{"spacing", v.spacingFlag, "5"},
+		{"jobs", v.jobsFlag, 4},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.setting, tt.got, tt.want)
+		}
+	}
+
+	for _, tc := range []struct{ line, err string }{
+		{"bogus = 1", `unknown setting "bogus"`},
+		{"config = other.conf", "config can only be given on the command line"},
+		{"version", "version can only be given on the command line"},
+		{`chars = "\q"`, "invalid quoted value"},
+		{`chars = "A" "B"`, "invalid quoted value"},
+		{"force = maybe", "invalid value for force"},
+		{"emit-meta = 1x", "invalid value for emit-meta"},
+		{"jobs = many", "invalid value for jobs"},
+	} {
+		_, _, err := parseWithConfig(t, nil, "# first line\n"+tc.line)
+		if err == nil || !strings.Contains(err.Error(), ":2: "+tc.err) {
+			t.Errorf("%q: err = %v, want line 2: %s", tc.line, err, tc.err)
+		}
+	}
+}
+
+func TestMergeChars(t *testing.T) {
+	tests := []struct{ chars, file, want string }{
+		{"ABC", "", "ABC"},
+		{"", "AB\nC\r\n", "ABC"},
+		{"AB", "BCA\nD", "ABCD"},
+		{"A", "\uFEFFBéé", "ABé"}, // A byte order mark is left out
+		{"\n", "\n", "\n"},        // --chars keeps what it was given
+	}
+	for _, tt := range tests {
+		if got := mergeChars(tt.chars, tt.file); got != tt.want {
+			t.Errorf("mergeChars(%q, %q) = %q, want %q", tt.chars, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestJobKey(t *testing.T) {
+	tests := []struct {
+		input FontInput
+		size  float64
+		key   string
+	}{
+		{FontInput{Path: filepath.Join("fonts", "Go.ttf")}, 32, "fonts/Go.ttf#0@32"},
+		{FontInput{Path: "Go.ttc", FaceIndex: 2}, 10.5, "Go.ttc#2@10.5"},
+		{FontInput{Path: "Go.ttf"}, 0, "Go.ttf#0@0"}, // --fit
+		{FontInput{Path: filepath.Join("C#@2x", "Font#1@home.ttf"), FaceIndex: 1}, 12, "C#@2x/Font#1@home.ttf#1@12"},
+	}
+	for _, tt := range tests {
+		key := jobKey(tt.input, tt.size)
+		if key != tt.key {
+			t.Errorf("jobKey(%+v, %g) = %q, want %q", tt.input, tt.size, key, tt.key)
+		}
+		if path := jobFont(key); path != tt.input.Path {
+			t.Errorf("jobFont(%q) = %q, want %q", key, path, tt.input.Path)
+		}
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	a, b, c := filepath.Join("fonts", "A.ttf"), filepath.Join("fonts", "B.ttf"), "chars.txt"
+	old, touched := fileStamp{size: 10, modTime: 1}, fileStamp{size: 10, modTime: 2}
+	tests := []struct {
+		before, after map[string]fileStamp
+		want          string
+	}{
+		{map[string]fileStamp{a: old}, map[string]fileStamp{a: old}, ""},
+		{nil, map[string]fileStamp{b: old, a: old}, "added A.ttf, B.ttf"},
+		{map[string]fileStamp{a: old, c: old}, map[string]fileStamp{a: touched, c: {size: 11, modTime: 1}}, "changed chars.txt, A.ttf"},
+		{map[string]fileStamp{a: old, b: old}, map[string]fileStamp{b: old}, "removed A.ttf"},
+		{map[string]fileStamp{a: old, b: old}, map[string]fileStamp{b: touched, c: old}, "added chars.txt; changed B.ttf; removed A.ttf"},
+	}
+	for _, tt := range tests {
+		if got := describeChanges(tt.before, tt.after); got != tt.want {
+			t.Errorf("describeChanges(%v, %v) = %q, want %q", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestPaddingNotice(t *testing.T) {
+	tests := []struct {
+		args   []string
+		config string
+		notice bool
+	}{
+		{nil, "", false},
+		{[]string{"--padding", "1"}, "", true},
+		{[]string{"--padding", "1", "--spacing", "2"}, "", false},
+		{[]string{"--padding", "1", "-p", "2"}, "", false},
+		{[]string{"-p", "2"}, "", false},
+		{nil, "padding = 1", true},
+		{[]string{"--padding", "1"}, "spacing = 2", false},
+	}
+	for _, tt := range tests {
+		args := append([]string{"-f", "fonts", "-s", "12", "-c", "A"}, tt.args...)
+		v, fs, err := parseWithConfig(t, args, tt.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := v.config(fs)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		if got := len(cfg.Notices) > 0; got != tt.notice {
+			t.Errorf("%q with %q: notices %q, want a deprecation notice: %v", tt.args, tt.config, cfg.Notices, tt.notice)
+		}
+	}
+}